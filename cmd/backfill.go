@@ -0,0 +1,115 @@
+// Copyright 2022
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"os"
+	"runtime"
+
+	"github.com/penny-vault/import-zacks-rank/zacks"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var backfillCmd = &cobra.Command{
+	Use:   "backfill <file|directory|glob> ...",
+	Args:  cobra.MinimumNArgs(1),
+	Short: "load archived zacks screens in bulk",
+	Long: `Load archived zacks_custom_screen_*.csv exports from files, directories
+(searched recursively) or glob patterns. Files are parsed in parallel and
+loaded in date order, one parquet file per date. Dates already present in
+zacks_financials are skipped, and completed dates are recorded in a checkpoint
+file so an interrupted backfill resumes where it left off.
+
+Each file is dated by its zacks_custom_screen_YYYY-MM-DD name or its archive
+path; files that carry no date are reported and not loaded. --date may only be
+used with a single file.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx := cmd.Context()
+		requireCurrentSchema(ctx)
+
+		files, err := zacks.ExpandInputs(args)
+		if err != nil {
+			log.Fatal().Err(err).Msg("could not expand inputs")
+		}
+
+		explicitDate := viper.GetString("event_date")
+		if explicitDate != "" && len(files) > 1 {
+			log.Fatal().Int("Files", len(files)).Msg("--date would give every file the same date; it can only be used to backfill a single file")
+		}
+
+		checkpoint, err := zacks.LoadCheckpoint(viper.GetString("backfill.checkpoint"))
+		if err != nil {
+			log.Fatal().Err(err).Msg("could not load checkpoint")
+		}
+
+		var existing map[string]bool
+		if !viper.GetBool("backfill.reload") {
+			if existing, err = zacks.ExistingEventDates(ctx); err != nil {
+				log.Fatal().Err(err).Msg("could not read existing dates from database")
+			}
+		}
+
+		parquetDir := viper.GetString("backfill.parquet_dir")
+		if parquetDir != "" {
+			if err := os.MkdirAll(parquetDir, 0755); err != nil {
+				log.Fatal().Err(err).Str("Dir", parquetDir).Msg("could not create parquet directory")
+			}
+		}
+
+		sinks := []zacks.Sink{&zacks.ParquetSink{Dir: parquetDir}, &zacks.DatabaseSink{}}
+		if viper.GetBool("backfill.upload") {
+			sinks = append(sinks, &zacks.ArchiveSink{Archiver: archiver()})
+		}
+
+		backfill := &zacks.Backfill{
+			Pipeline:   zacks.NewPipeline(nil, sinks...),
+			Workers:    viper.GetInt("backfill.workers"),
+			Checkpoint: checkpoint,
+			Existing:   existing,
+			Dates:      zacks.BackfillDateResolver(explicitDate),
+		}
+
+		stats, err := backfill.Run(ctx, files)
+		if err != nil {
+			log.Fatal().Err(err).Msg("backfill failed")
+		}
+
+		if stats.Failed > 0 {
+			log.Error().Int("Failed", stats.Failed).Msg("some dates failed to load; fix them and re-run to resume")
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	backfillCmd.Flags().Int("workers", runtime.NumCPU(), "number of files to parse in parallel")
+	viper.BindPFlag("backfill.workers", backfillCmd.Flags().Lookup("workers"))
+
+	backfillCmd.Flags().String("checkpoint", "import-zacks-backfill.json", "file recording completed dates so an interrupted backfill can resume")
+	viper.BindPFlag("backfill.checkpoint", backfillCmd.Flags().Lookup("checkpoint"))
+
+	backfillCmd.Flags().String("parquet-dir", "", "directory to keep one parquet file per date in (default: temporary)")
+	viper.BindPFlag("backfill.parquet_dir", backfillCmd.Flags().Lookup("parquet-dir"))
+
+	backfillCmd.Flags().Bool("upload", false, "upload each date's parquet file to the archive")
+	viper.BindPFlag("backfill.upload", backfillCmd.Flags().Lookup("upload"))
+
+	backfillCmd.Flags().Bool("reload", false, "load dates even if they are already in zacks_financials")
+	viper.BindPFlag("backfill.reload", backfillCmd.Flags().Lookup("reload"))
+
+	rootCmd.AddCommand(backfillCmd)
+}
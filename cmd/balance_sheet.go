@@ -15,12 +15,12 @@
 package cmd
 
 import (
-	"context"
 	"math/rand"
 	"os"
 	"time"
 
 	"github.com/jackc/pgx/v4"
+	"github.com/penny-vault/import-zacks-rank/storage"
 	"github.com/penny-vault/import-zacks-rank/zacks"
 	"github.com/rs/zerolog/log"
 	"github.com/spf13/cobra"
@@ -37,7 +37,8 @@ var balanceSheetCmd = &cobra.Command{
 	Args:  cobra.MinimumNArgs(0),
 	Short: "load balance sheet from zacks",
 	Run: func(cmd *cobra.Command, args []string) {
-		ctx := context.Background()
+		ctx := cmd.Context()
+		requireCurrentSchema(ctx)
 
 		conn, err := pgx.Connect(ctx, viper.GetString("database.url"))
 		if err != nil {
@@ -105,14 +106,39 @@ var balanceSheetCmd = &cobra.Command{
 			os.Exit(0)
 		}
 
-		if balanceSheets, err := zacks.BalanceSheet(args); err == nil {
+		downloadCtx, cancel := downloadContext(ctx)
+		balanceSheets, err := zacks.BalanceSheet(downloadCtx, args)
+		cancel()
+		failed := err != nil
+		if err != nil {
+			log.Error().Err(err).Int("Downloaded", len(balanceSheets)).Msg("caught error when parsing balance sheet")
+		}
+
+		if len(balanceSheets) > 0 {
 			log.Info().Int("Count", len(balanceSheets)).Msg("saving balance sheets to database")
-			balanceSheets.SaveToDB(ctx, conn)
+			_, dbErr := balanceSheets.SaveToDB(ctx, conn)
+			if dbErr != nil {
+				log.Error().Err(dbErr).Msg("failed to save balance sheets to database")
+				failed = true
+			}
+
+			// the parquet file is always written so a failed load can be
+			// inspected, but it is only archived once the database has it
 			if err := balanceSheets.SaveToParquet("balance_sheet_info.parquet"); err != nil {
 				log.Error().Err(err).Msg("failed to save to parquet")
+				failed = true
+			} else if dbErr == nil && viper.GetBool("balance_sheet.upload") {
+				today := time.Now()
+				date := time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, time.UTC)
+				if _, err := archiver().Archive(ctx, storage.DatasetBalanceSheet, date, "balance_sheet_info.parquet", len(balanceSheets), zacks.BalanceSheetSchemaVersion); err != nil {
+					log.Error().Err(err).Msg("failed to archive balance sheets")
+					failed = true
+				}
 			}
-		} else {
-			log.Error().Err(err).Msg("caught error when parsing balance sheet")
+		}
+
+		if failed {
+			log.Fatal().Msg("balance sheet import failed")
 		}
 	},
 }
@@ -121,5 +147,8 @@ func init() {
 	balanceSheetCmd.LocalFlags().IntVar(&lookback, "lookback", 30, "Number of days to lookback")
 	balanceSheetCmd.LocalFlags().IntVar(&maxAssets, "max-assets", 25, "Maximum number of discovered assets to include")
 
+	balanceSheetCmd.Flags().Bool("upload", false, "upload the balance sheet parquet file to the archive")
+	viper.BindPFlag("balance_sheet.upload", balanceSheetCmd.Flags().Lookup("upload"))
+
 	rootCmd.AddCommand(balanceSheetCmd)
 }
@@ -0,0 +1,104 @@
+// Copyright 2022
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/gocarina/gocsv"
+	"github.com/penny-vault/import-zacks-rank/zacks"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var diffCmd = &cobra.Command{
+	Use:   "diff",
+	Args:  cobra.NoArgs,
+	Short: "compare two ratings snapshots",
+	Long: `Compare two ratings snapshots and report tickers added or dropped, Zacks
+Rank upgrades and downgrades, style-score changes and the biggest movers in
+the Q1, F1 and F2 consensus estimates.
+
+Each side is read from zacks_financials for --from / --to, or from a local
+parquet or CSV file given with --from-file / --to-file.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx := cmd.Context()
+
+		fromDate, from, err := zacks.LoadSnapshot(ctx, viper.GetString("diff.from"), viper.GetString("diff.from_file"))
+		if err != nil {
+			log.Fatal().Err(err).Msg("could not load --from snapshot")
+		}
+
+		toDate, to, err := zacks.LoadSnapshot(ctx, viper.GetString("diff.to"), viper.GetString("diff.to_file"))
+		if err != nil {
+			log.Fatal().Err(err).Msg("could not load --to snapshot")
+		}
+
+		diff := zacks.DiffSnapshots(fromDate, from, toDate, to, viper.GetInt("diff.top"))
+
+		log.Info().Str("From", fromDate).Str("To", toDate).
+			Int("Added", len(diff.Added)).Int("Dropped", len(diff.Dropped)).
+			Int("Upgrades", len(diff.Upgrades)).Int("Downgrades", len(diff.Downgrades)).
+			Int("ScoreChanges", len(diff.ScoreChanges)).Msg("snapshots compared")
+
+		switch format := viper.GetString("diff.format"); format {
+		case "table":
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "CHANGE\tTICKER\tCOMPANY\tFIELD\tFROM\tTO\tPCT")
+			for _, row := range diff.Rows() {
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", row.Change, row.Ticker, row.CompanyName, row.Field, row.From, row.To, row.Percent)
+			}
+			w.Flush()
+		case "csv":
+			if err := gocsv.Marshal(diff.Rows(), os.Stdout); err != nil {
+				log.Fatal().Err(err).Msg("could not write csv")
+			}
+		case "json":
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			if err := enc.Encode(diff); err != nil {
+				log.Fatal().Err(err).Msg("could not write json")
+			}
+		default:
+			log.Fatal().Str("Format", format).Msg("unknown output format; expected table, csv or json")
+		}
+	},
+}
+
+func init() {
+	diffCmd.Flags().String("from", "", "date (YYYY-MM-DD) of the earlier snapshot in zacks_financials")
+	viper.BindPFlag("diff.from", diffCmd.Flags().Lookup("from"))
+
+	diffCmd.Flags().String("to", "", "date (YYYY-MM-DD) of the later snapshot in zacks_financials")
+	viper.BindPFlag("diff.to", diffCmd.Flags().Lookup("to"))
+
+	diffCmd.Flags().String("from-file", "", "read the earlier snapshot from a parquet or CSV file")
+	viper.BindPFlag("diff.from_file", diffCmd.Flags().Lookup("from-file"))
+
+	diffCmd.Flags().String("to-file", "", "read the later snapshot from a parquet or CSV file")
+	viper.BindPFlag("diff.to_file", diffCmd.Flags().Lookup("to-file"))
+
+	diffCmd.Flags().String("format", "table", "output format: table, csv or json")
+	viper.BindPFlag("diff.format", diffCmd.Flags().Lookup("format"))
+
+	diffCmd.Flags().Int("top", 20, "number of consensus estimate movers to report (0 for all)")
+	viper.BindPFlag("diff.top", diffCmd.Flags().Lookup("top"))
+
+	rootCmd.AddCommand(diffCmd)
+}
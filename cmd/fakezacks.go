@@ -0,0 +1,64 @@
+// Copyright 2022
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/penny-vault/import-zacks-rank/zacks/fakezacks"
+	"github.com/spf13/cobra"
+)
+
+var fakeZacksCmd = &cobra.Command{
+	Use:    "fakezacks",
+	Args:   cobra.NoArgs,
+	Hidden: true,
+	Short:  "serve a local stand-in for zacks.com",
+	Long: `Serve the login, stock screener and balance sheet pages that the download
+commands drive, so they can be exercised offline. Copy the printed settings
+into a config file and run e.g. 'import-zacks-rank test' against it.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		username, _ := cmd.Flags().GetString("username")
+		password, _ := cmd.Flags().GetString("password")
+
+		server := fakezacks.New(username, password)
+		defer server.Close()
+
+		fmt.Printf(`[zacks]
+username = %q
+password = %q
+
+[zacks.urls]
+homepage = "%s/"
+login = "%s/logout.php"
+stock_screener = "%s/screening/stock-screener"
+balance_sheet = "%s/stock/quote/%%s/balance-sheet"
+`, username, password, server.URL, server.URL, server.URL, server.URL)
+
+		sig := make(chan os.Signal, 1)
+		signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
+		<-sig
+	},
+}
+
+func init() {
+	fakeZacksCmd.Flags().String("username", "user@example.com", "username the fake site accepts")
+	fakeZacksCmd.Flags().String("password", "secret", "password the fake site accepts")
+
+	rootCmd.AddCommand(fakeZacksCmd)
+}
@@ -15,17 +15,9 @@
 package cmd
 
 import (
-	"fmt"
-	"io"
-	"os"
-	"regexp"
-	"strings"
-
-	"github.com/penny-vault/import-zacks-rank/backblaze"
 	"github.com/penny-vault/import-zacks-rank/zacks"
 	"github.com/rs/zerolog/log"
 	"github.com/spf13/cobra"
-	"github.com/spf13/viper"
 )
 
 var inputFile string
@@ -34,67 +26,33 @@ var fileCmd = &cobra.Command{
 	Use:   "file",
 	Args:  cobra.ExactArgs(1),
 	Short: "load zacks rank from file",
-	Long:  `Print the version number`,
+	Long: `Load a previously downloaded zacks screen from disk. The event date is
+taken from --date, the zacks_custom_screen_YYYY-MM-DD filename, archive path
+conventions (zacks-YYYYMMDD, date=YYYY-MM-DD, YYYY/MM/DD/), the file
+modification time or the last trading day, in that order. The file is parsed,
+loaded and archived according to the schema, table and dataset of --screen.`,
 	Run: func(cmd *cobra.Command, args []string) {
-		outputFilename := args[0]
-
-		// parse date from filename :(
-		// if that doesn't work use the current date
-		regex := regexp.MustCompile(`zacks_custom_screen_(\d{4}-\d{2}-\d{2})`)
-		match := regex.FindAllStringSubmatch(outputFilename, -1)
-		var dateStr string
-		if len(match) > 0 {
-			dateStr = match[0][1]
-		} else {
-			log.Error().Str("FileName", outputFilename).Msg("cannot extract date from filename, expecting zacks_custom_screen_YYYY-MM-DD")
-			return
-		}
+		ctx := cmd.Context()
+		requireCurrentSchema(ctx)
 
-		// read data from file
-		fh, err := os.Open(outputFilename)
+		name, _ := cmd.Flags().GetString("screen")
+		screen, err := zacks.FindScreen(name)
 		if err != nil {
-			log.Fatal().Str("Filename", outputFilename).Err(err).Msg("could not read input file")
-		}
-
-		data, err := io.ReadAll(fh)
-		if err != nil {
-			log.Fatal().Err(err).Msg("could not read input file")
-		}
-
-		// parse file
-		ratings := zacks.LoadRatings(data, dateStr, viper.GetInt("limit"))
-		log.Info().Int("NumRatings", len(ratings)).Msg("loaded ratings")
-		if len(ratings) == 0 {
-			log.Fatal().Msg("no ratings returned")
+			log.Fatal().Err(err).Str("Screen", name).Msg("invalid --screen")
 		}
-		zacks.EnrichWithFigi(ratings)
 
-		// Save data as parquet to a temporary directory
-		tmpdir, err := os.MkdirTemp(os.TempDir(), "import-zacks")
-		if err != nil {
-			log.Error().Err(err).Msg("could not create tempdir")
-		}
-		dateStr = strings.ReplaceAll(dateStr, "-", "")
-		parquetFn := fmt.Sprintf("%s/zacks-%s.parquet", tmpdir, dateStr)
-		log.Info().Str("FileName", parquetFn).Msg("writing zacks ratings data to parquet")
-		zacks.SaveToParquet(ratings, parquetFn)
+		sinks := screenSinks(screen, archiver(), exportSinks(cmd))
+		pipeline := zacks.NewScreenPipeline(screen, &zacks.FileSource{Path: args[0], Screen: screen}, sinks...)
 
-		// Save to database
-		err = zacks.SaveToDB(ratings)
-		if err != nil {
-			log.Fatal().Err(err).Msg("could not save to DB")
+		if _, err := pipeline.Run(ctx); err != nil {
+			log.Fatal().Err(err).Msg("import failed")
 		}
-
-		// Upload to backblaze
-		year := string(dateStr[:4])
-		log.Info().Str("Year", year).Str("Bucket", viper.GetString("backblaze.bucket")).Msg("data")
-		backblaze.UploadToBackBlaze(parquetFn, viper.GetString("backblaze.bucket"), year)
-
-		// Cleanup after ourselves
-		os.RemoveAll(tmpdir)
 	},
 }
 
 func init() {
+	fileCmd.Flags().StringArray("export", nil, exportUsage)
+	fileCmd.Flags().String("screen", "", "name of the configured screen the file was downloaded from (default the first screen)")
+
 	rootCmd.AddCommand(fileCmd)
 }
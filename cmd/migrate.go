@@ -0,0 +1,150 @@
+// Copyright 2022
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/penny-vault/import-zacks-rank/migrations"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var migrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "manage the database schema used by import-zacks-rank",
+}
+
+var migrateUpCmd = &cobra.Command{
+	Use:   "up [N]",
+	Args:  cobra.MaximumNArgs(1),
+	Short: "apply pending migrations (all, or the next N)",
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx := cmd.Context()
+		conn := connectForMigrate(ctx)
+		defer conn.Close(ctx)
+
+		cnt, err := migrations.Up(ctx, conn, migrateSteps(args))
+		if err != nil {
+			log.Fatal().Err(err).Int("Applied", cnt).Msg("migrate up failed")
+		}
+
+		log.Info().Int("Applied", cnt).Int("Version", migrations.Latest()).Msg("database schema is up to date")
+	},
+}
+
+var migrateDownCmd = &cobra.Command{
+	Use:   "down [N]",
+	Args:  cobra.MaximumNArgs(1),
+	Short: "revert the last N applied migrations (default 1)",
+	Long: `Revert the last N applied migrations (default 1). Reverting a migration
+that creates a table drops the table and its data, so those migrations are
+only reverted with --force.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx := cmd.Context()
+		conn := connectForMigrate(ctx)
+		defer conn.Close(ctx)
+
+		force, _ := cmd.Flags().GetBool("force")
+		cnt, err := migrations.Down(ctx, conn, migrateSteps(args), force)
+		if err != nil {
+			log.Fatal().Err(err).Int("Reverted", cnt).Msg("migrate down failed")
+		}
+
+		log.Info().Int("Reverted", cnt).Msg("migrations reverted")
+	},
+}
+
+var migrateStatusCmd = &cobra.Command{
+	Use:   "status",
+	Args:  cobra.NoArgs,
+	Short: "list migrations and whether they have been applied",
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx := cmd.Context()
+		conn := connectForMigrate(ctx)
+		defer conn.Close(ctx)
+
+		statuses, err := migrations.StatusOf(ctx, conn)
+		if err != nil {
+			log.Fatal().Err(err).Msg("could not read migration status")
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED AT")
+		for _, status := range statuses {
+			appliedAt := "pending"
+			if status.Applied {
+				appliedAt = status.AppliedAt.Format("2006-01-02 15:04:05 MST")
+			}
+			fmt.Fprintf(w, "%04d\t%s\t%s\n", status.Version, status.Name, appliedAt)
+		}
+		w.Flush()
+	},
+}
+
+func connectForMigrate(ctx context.Context) *pgx.Conn {
+	conn, err := pgx.Connect(ctx, viper.GetString("database.url"))
+	if err != nil {
+		log.Fatal().Err(err).Msg("could not connect to database")
+	}
+	return conn
+}
+
+func migrateSteps(args []string) int {
+	if len(args) == 0 {
+		return 0
+	}
+
+	steps, err := strconv.Atoi(args[0])
+	if err != nil || steps < 1 {
+		log.Fatal().Str("N", args[0]).Msg("N must be a positive integer")
+	}
+	return steps
+}
+
+// requireCurrentSchema exits when the database schema is behind the migrations
+// embedded in this binary; import commands call it before touching the database
+func requireCurrentSchema(ctx context.Context) {
+	conn, err := pgx.Connect(ctx, viper.GetString("database.url"))
+	if err != nil {
+		log.Fatal().Err(err).Msg("could not connect to database")
+	}
+	defer conn.Close(ctx)
+
+	err = migrations.EnsureCurrent(ctx, conn)
+	switch {
+	case errors.Is(err, migrations.ErrSchemaAhead):
+		log.Warn().Err(err).Msg("database schema is newer than expected")
+	case err != nil:
+		log.Fatal().Err(err).Msg("refusing to import")
+	}
+}
+
+func init() {
+	migrateDownCmd.Flags().Bool("force", false, "also revert migrations whose down script deletes data")
+
+	migrateCmd.AddCommand(migrateUpCmd)
+	migrateCmd.AddCommand(migrateDownCmd)
+	migrateCmd.AddCommand(migrateStatusCmd)
+
+	rootCmd.AddCommand(migrateCmd)
+}
@@ -0,0 +1,111 @@
+// Copyright 2022
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"os"
+	"sort"
+	"time"
+
+	"github.com/penny-vault/import-zacks-rank/storage"
+	"github.com/penny-vault/import-zacks-rank/zacks"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var restoreCmd = &cobra.Command{
+	Use:   "restore [YYYY-MM-DD ...]",
+	Short: "reload archived ratings into the database",
+	Long: `Download archived ratings parquet files and load them into zacks_financials,
+e.g. to rebuild the table after it was lost or truncated. Dates are given as
+arguments, or as a range with --start and --end in which case every archived
+date in the range is restored. Both the legacy and the partitioned archive
+layouts are recognized, and files written with an older parquet schema are
+converted to the current one.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx := cmd.Context()
+		requireCurrentSchema(ctx)
+
+		archiver := archiver()
+
+		// date -> key; an empty key is located when the date is restored
+		todo := make(map[string]string)
+		for _, arg := range args {
+			if _, err := time.Parse("2006-01-02", arg); err != nil {
+				log.Fatal().Str("Date", arg).Msg("dates must be formatted YYYY-MM-DD")
+			}
+			todo[arg] = ""
+		}
+
+		start := viper.GetString("restore.start")
+		end := viper.GetString("restore.end")
+		if start != "" || end != "" {
+			partitions, err := archiver.Partitions(ctx, storage.DatasetRatings)
+			if err != nil {
+				log.Fatal().Err(err).Msg("could not list archived dates")
+			}
+			for dateStr, key := range partitions {
+				if (start == "" || dateStr >= start) && (end == "" || dateStr <= end) {
+					todo[dateStr] = key
+				}
+			}
+		}
+
+		if len(todo) == 0 {
+			log.Fatal().Msg("nothing to restore; pass dates or a --start/--end range with archived dates")
+		}
+
+		dates := make([]string, 0, len(todo))
+		for dateStr := range todo {
+			dates = append(dates, dateStr)
+		}
+		sort.Strings(dates)
+
+		failed := 0
+		for _, dateStr := range dates {
+			date, _ := time.Parse("2006-01-02", dateStr)
+			pipeline := &zacks.Pipeline{
+				Source: &zacks.ArchiveSource{Archiver: archiver, Date: date, Key: todo[dateStr]},
+				Parse:  &zacks.ParquetParseStage{},
+				Sinks:  []zacks.Sink{&zacks.DatabaseSink{}},
+			}
+
+			batch, err := pipeline.Run(ctx)
+			if err != nil {
+				log.Error().Err(err).Str("EventDate", dateStr).Msg("restore of date failed")
+				failed++
+				continue
+			}
+
+			log.Info().Str("EventDate", dateStr).Int64("Inserted", batch.LoadStats.Inserted).Int64("Updated", batch.LoadStats.Updated).Msg("date restored")
+		}
+
+		log.Info().Int("Dates", len(dates)).Int("Failed", failed).Msg("restore finished")
+		if failed > 0 {
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	restoreCmd.Flags().String("start", "", "restore every archived date on or after YYYY-MM-DD")
+	viper.BindPFlag("restore.start", restoreCmd.Flags().Lookup("start"))
+
+	restoreCmd.Flags().String("end", "", "restore every archived date on or before YYYY-MM-DD")
+	viper.BindPFlag("restore.end", restoreCmd.Flags().Lookup("end"))
+
+	rootCmd.AddCommand(restoreCmd)
+}
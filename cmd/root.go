@@ -17,12 +17,15 @@ limitations under the License.
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"os"
-	"regexp"
+	"os/signal"
 	"strings"
+	"syscall"
+	"time"
 
-	"github.com/penny-vault/import-zacks-rank/backblaze"
+	"github.com/penny-vault/import-zacks-rank/storage"
 	"github.com/penny-vault/import-zacks-rank/zacks"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
@@ -39,70 +42,132 @@ var rootCmd = &cobra.Command{
 	Short: "Download and import ratings from Zacks stock screener",
 	// Long: ``,
 	Run: func(cmd *cobra.Command, args []string) {
-		var data []byte
-		var outputFilename string
-		var err error
-
-		for ii := 0; ii < viper.GetInt("zacks.max_retries"); ii++ {
-			data, outputFilename, err = zacks.Download()
-			if err == nil {
-				break
-			}
+		ctx := cmd.Context()
+		requireCurrentSchema(ctx)
+
+		screens, err := zacks.ConfiguredScreens()
+		if err != nil {
+			log.Fatal().Err(err).Msg("invalid screen configuration")
 		}
-		// after multiple retries check if the download succeeded
+
+		exports := exportSinks(cmd)
+		requireScreenPlaceholder(screens, exports)
+		archive := archiver()
+
+		// every screen is downloaded in one browser session before any is imported
+		downloadCtx, cancel := downloadContext(ctx)
+		source := &zacks.ScreensSource{Screens: screens, MaxRetries: viper.GetInt("zacks.max_retries"), Backoff: zacks.ConfiguredBackoff()}
+		batches, err := source.FetchAll(downloadCtx)
+		cancel()
+		failed := err != nil
 		if err != nil {
-			os.Exit(1)
+			log.Error().Err(err).Msg("download failed")
 		}
 
-		// parse date from filename :(
-		// if that doesn't work use the current date
-		regex := regexp.MustCompile(`zacks_custom_screen_(\d{4}-\d{2}-\d{2})`)
-		match := regex.FindAllStringSubmatch(outputFilename, -1)
-		var dateStr string
-		if len(match) > 0 {
-			dateStr = match[0][1]
-		} else {
-			log.Error().Str("FileName", outputFilename).Msg("cannot extract date from filename, expecting zacks_custom_screen_YYYY-MM-DD")
-			return
+		// one enricher reads the assets table once for every screen
+		enrich := &zacks.FigiEnricher{}
+		for _, batch := range batches {
+			pipeline := zacks.NewScreenPipeline(batch.Screen, &zacks.BatchSource{Batch: batch}, screenSinks(batch.Screen, archive, exports)...)
+			if pipeline.Enrich != nil {
+				pipeline.Enrich = enrich
+			}
+			if _, err := pipeline.Run(ctx); err != nil {
+				log.Error().Err(err).Str("Screen", batch.Screen.Name).Msg("import of screen failed")
+				failed = true
+			}
 		}
 
-		ratings := zacks.LoadRatings(data, dateStr, viper.GetInt("limit"))
-		log.Info().Int("NumRatings", len(ratings)).Msg("loaded ratings")
-		if len(ratings) == 0 {
-			log.Fatal().Msg("no ratings returned")
+		if failed {
+			log.Fatal().Msg("import failed")
 		}
-		zacks.EnrichWithFigi(ratings)
+	},
+}
 
-		// Save data as parquet to a temporary directory
-		tmpdir, err := os.MkdirTemp(os.TempDir(), "import-zacks")
-		if err != nil {
-			log.Error().Err(err).Msg("could not create tempdir")
+// screenSinks returns the sinks a screen is delivered to. Ratings screens are
+// written to parquet, loaded into the screen's table if it has one, archived
+// and exported; raw screens are only written to parquet and archived.
+func screenSinks(screen *zacks.Screen, archive *storage.Archiver, exports []zacks.Sink) []zacks.Sink {
+	if screen.Schema == zacks.SchemaRaw {
+		return []zacks.Sink{&zacks.RawParquetSink{}, &zacks.ArchiveSink{Archiver: archive}}
+	}
+
+	sinks := []zacks.Sink{&zacks.ParquetSink{}}
+	if screen.Table != "" {
+		sinks = append(sinks, &zacks.DatabaseSink{Table: screen.Table})
+	}
+	sinks = append(sinks, &zacks.ArchiveSink{Archiver: archive})
+	return append(sinks, exports...)
+}
+
+// requireScreenPlaceholder exits when more than one ratings screen would be
+// exported to the same path
+func requireScreenPlaceholder(screens []*zacks.Screen, exports []zacks.Sink) {
+	ratingsScreens := 0
+	for _, screen := range screens {
+		if screen.Schema == zacks.SchemaRatings {
+			ratingsScreens++
 		}
-		dateStr = strings.ReplaceAll(dateStr, "-", "")
-		parquetFn := fmt.Sprintf("%s/zacks-%s.parquet", tmpdir, dateStr)
-		log.Info().Str("FileName", parquetFn).Msg("writing zacks ratings data to parquet")
-		zacks.SaveToParquet(ratings, parquetFn)
+	}
+	if ratingsScreens < 2 {
+		return
+	}
 
-		// Save to database
-		err = zacks.SaveToDB(ratings)
-		if err != nil {
-			log.Fatal().Err(err).Msg("could not save to database")
+	for _, sink := range exports {
+		if export, ok := sink.(*zacks.ExportSink); ok && !strings.Contains(export.Path, "{screen}") {
+			log.Fatal().Str("Path", export.Path).Msg("--export path must contain {screen} when several ratings screens are configured")
 		}
+	}
+}
 
-		// Upload to backblaze
-		year := string(dateStr[:4])
-		log.Info().Str("Year", year).Str("Bucket", viper.GetString("backblaze.bucket")).Msg("data")
-		backblaze.UploadToBackBlaze(parquetFn, viper.GetString("backblaze.bucket"), year)
+// downloadContext bounds a download from zacks.com, including its retries, by
+// zacks.download_timeout; a timeout of 0 only cancels with ctx
+func downloadContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	timeout := viper.GetDuration("zacks.download_timeout")
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
 
-		// Cleanup after ourselves
-		os.RemoveAll(tmpdir)
-	},
+// archiver returns an archiver for the configured store and layout or exits
+func archiver() *storage.Archiver {
+	archiver, err := storage.NewArchiver()
+	if err != nil {
+		log.Fatal().Err(err).Msg("could not configure archive storage")
+	}
+	return archiver
 }
 
+// exportSinks returns a sink for each --export format=path, or for each entry
+// of the export config list when the flag is not given
+func exportSinks(cmd *cobra.Command) []zacks.Sink {
+	specs := viper.GetStringSlice("export")
+	if cmd.Flags().Changed("export") {
+		specs, _ = cmd.Flags().GetStringArray("export")
+	}
+
+	sinks := make([]zacks.Sink, 0, len(specs))
+	for _, spec := range specs {
+		exporter, fn, err := zacks.ParseExportSpec(spec)
+		if err != nil {
+			log.Fatal().Err(err).Msg("invalid --export")
+		}
+		sinks = append(sinks, &zacks.ExportSink{Exporter: exporter, Path: fn})
+	}
+	return sinks
+}
+
+// exportUsage is shared by the commands that accept --export
+const exportUsage = "also export the ratings as format=path (parquet, csv, jsonl or arrow); {date} and {screen} in path are replaced with the event date and screen name; may be repeated"
+
 // Execute adds all child commands to the root command and sets flags appropriately.
 // This is called by main.main(). It only needs to happen once to the rootCmd.
+// SIGINT and SIGTERM cancel the context the commands run with so that browsers
+// are shut down and partial work is saved before exiting.
 func Execute() {
-	err := rootCmd.Execute()
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	err := rootCmd.ExecuteContext(ctx)
+	stop()
 	if err != nil {
 		log.Error().Msg(err.Error())
 		os.Exit(1)
@@ -118,10 +183,69 @@ func init() {
 	rootCmd.PersistentFlags().Bool("log-json", false, "print logs as json to stderr")
 	viper.BindPFlag("log.json", rootCmd.PersistentFlags().Lookup("log-json"))
 
+	rootCmd.PersistentFlags().String("date", "", "event date of the screen (YYYY-MM-DD); by default it is taken from the download filename")
+	viper.BindPFlag("event_date", rootCmd.PersistentFlags().Lookup("date"))
+
+	rootCmd.PersistentFlags().Bool("strict", false, "fail the import when the screener columns do not match the expected columns")
+	viper.BindPFlag("zacks.strict_columns", rootCmd.PersistentFlags().Lookup("strict"))
+
+	rootCmd.PersistentFlags().Bool("na-as-zero", false, "load missing (NA) values as 0 instead of NULL, as earlier releases did")
+	viper.BindPFlag("zacks.na_as_zero", rootCmd.PersistentFlags().Lookup("na-as-zero"))
+
+	rootCmd.PersistentFlags().String("rejects-dir", ".", "directory rows that fail validation are written to as <dataset>-rejects-YYYYMMDD.parquet; empty only logs them")
+	viper.BindPFlag("validation.rejects_dir", rootCmd.PersistentFlags().Lookup("rejects-dir"))
+
+	rootCmd.PersistentFlags().String("on-error", "abort", "how to handle rows that fail to load into the database: abort (roll back the import) or skip (record and skip the row)")
+	viper.BindPFlag("database.on_error", rootCmd.PersistentFlags().Lookup("on-error"))
+
+	rootCmd.PersistentFlags().String("archive", "b2", "where parquet files are archived: b2, s3 or local")
+	viper.BindPFlag("archive.backend", rootCmd.PersistentFlags().Lookup("archive"))
+
+	rootCmd.PersistentFlags().String("archive-layout", "legacy", "archive key layout: legacy (<year>/zacks-YYYYMMDD.parquet) or partitioned (dataset=ratings/year=YYYY/month=MM/date=YYYY-MM-DD/part-0.parquet)")
+	viper.BindPFlag("archive.layout", rootCmd.PersistentFlags().Lookup("archive-layout"))
+
+	rootCmd.PersistentFlags().String("session-file", "", "file the encrypted browser session is saved to (default import-zacks-rank/session.enc in the user config directory)")
+	viper.BindPFlag("session.file", rootCmd.PersistentFlags().Lookup("session-file"))
+
+	rootCmd.PersistentFlags().Bool("no-session", false, "do not restore or save the browser session; log in on every run")
+	viper.BindPFlag("session.disabled", rootCmd.PersistentFlags().Lookup("no-session"))
+
+	rootCmd.PersistentFlags().Duration("download-timeout", 30*time.Minute, "give up downloading from zacks.com, including retries, after this long; 0 waits indefinitely")
+	viper.BindPFlag("zacks.download_timeout", rootCmd.PersistentFlags().Lookup("download-timeout"))
+
+	rootCmd.PersistentFlags().String("browser", "chromium", "browser engine: chromium, firefox or webkit")
+	viper.BindPFlag("playwright.browser", rootCmd.PersistentFlags().Lookup("browser"))
+
+	rootCmd.PersistentFlags().String("browser-mode", "launch", "launch a local browser, connect to a playwright browser server, or attach to a Chromium over cdp")
+	viper.BindPFlag("playwright.mode", rootCmd.PersistentFlags().Lookup("browser-mode"))
+
+	rootCmd.PersistentFlags().String("browser-endpoint", "", "ws:// or http:// URL of the remote browser when --browser-mode is connect or cdp")
+	viper.BindPFlag("playwright.endpoint", rootCmd.PersistentFlags().Lookup("browser-endpoint"))
+
+	rootCmd.PersistentFlags().StringArray("browser-arg", nil, "extra command line argument for a launched browser; may be repeated")
+	viper.BindPFlag("playwright.args", rootCmd.PersistentFlags().Lookup("browser-arg"))
+
+	rootCmd.PersistentFlags().String("browser-executable", "", "browser binary to launch instead of the one installed by playwright")
+	viper.BindPFlag("playwright.executable_path", rootCmd.PersistentFlags().Lookup("browser-executable"))
+
+	rootCmd.PersistentFlags().String("profile", "", "browser profile from [[profiles]] to use, or rotate to switch profile every time a browser is started")
+	viper.BindPFlag("playwright.profile", rootCmd.PersistentFlags().Lookup("profile"))
+
+	rootCmd.PersistentFlags().String("block-rules", "", "TOML file of request blocking rules (default: the built-in rules in common/block_rules.toml)")
+	viper.BindPFlag("playwright.block_rules", rootCmd.PersistentFlags().Lookup("block-rules"))
+
+	rootCmd.PersistentFlags().String("evidence-dir", "", "write a playwright trace, screenshot, page HTML and console log to this directory when a browser step fails; bundles may contain session cookies")
+	viper.BindPFlag("evidence.dir", rootCmd.PersistentFlags().Lookup("evidence-dir"))
+
+	rootCmd.PersistentFlags().Bool("upload-evidence", false, "also upload failure evidence, including any session cookies it holds, to the archive store under evidence/")
+	viper.BindPFlag("evidence.upload", rootCmd.PersistentFlags().Lookup("upload-evidence"))
+
 	// Add flags
 	rootCmd.Flags().StringP("database_url", "d", "host=localhost port=5432", "DSN for database connection")
 	viper.BindPFlag("database.url", rootCmd.Flags().Lookup("database_url"))
 
+	rootCmd.Flags().StringArray("export", nil, exportUsage)
+
 	rootCmd.Flags().Uint32P("limit", "l", 0, "limit results to N")
 	viper.BindPFlag("limit", rootCmd.Flags().Lookup("limit"))
 
@@ -139,6 +263,12 @@ func init() {
 
 	rootCmd.Flags().Int("max-retries", 3, "maximum number of times to retry if download fails")
 	viper.BindPFlag("zacks.max_retries", rootCmd.Flags().Lookup("max-retries"))
+
+	rootCmd.Flags().Duration("retry-delay", 30*time.Second, "wait before the first retry; the wait doubles, with jitter, after each failed attempt")
+	viper.BindPFlag("zacks.retry_delay", rootCmd.Flags().Lookup("retry-delay"))
+
+	rootCmd.Flags().Duration("retry-max-delay", 5*time.Minute, "longest wait between retries")
+	viper.BindPFlag("zacks.retry_max_delay", rootCmd.Flags().Lookup("retry-max-delay"))
 }
 
 // initConfig reads in config file and ENV variables if set.
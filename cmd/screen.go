@@ -0,0 +1,151 @@
+// Copyright 2022
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"os"
+
+	"github.com/penny-vault/import-zacks-rank/zacks"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+)
+
+var screenCmd = &cobra.Command{
+	Use:   "screen",
+	Short: "manage the saved Zacks screens from definition files",
+	Long: `A screen definition file (toml, yaml or json) lists the title, criteria and
+output columns of a saved screen:
+
+  title = "Zacks Rank Import"
+  id = 137005
+  columns = ["Company Name", "Ticker", ...]
+
+  [[criteria]]
+  field = "Zacks Rank"
+  operator = "<="
+  value = "5"
+
+The commands take definition files as arguments; without arguments they use
+the definition of every configured screen that has one.`,
+}
+
+var screenSyncCmd = &cobra.Command{
+	Use:   "sync [definition ...]",
+	Short: "create or update saved screens to match their definitions",
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, cancel := downloadContext(cmd.Context())
+		defer cancel()
+
+		failed := false
+		for _, def := range screenDefinitions(args) {
+			if _, err := zacks.SyncScreen(ctx, def); err != nil {
+				log.Error().Err(err).Str("Title", def.Title).Msg("screen sync failed")
+				failed = true
+			}
+		}
+
+		if failed {
+			os.Exit(1)
+		}
+	},
+}
+
+var screenVerifyCmd = &cobra.Command{
+	Use:   "verify [definition ...]",
+	Short: "compare saved screens with their definitions",
+	Long: `Compare the criteria and output columns of each saved screen with its
+definition and exit with status 1 if any differ. Definitions of configured
+ratings screens are also checked against the columns ZacksRecord reads.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, cancel := downloadContext(cmd.Context())
+		defer cancel()
+
+		failed := false
+		for _, def := range screenDefinitions(args) {
+			live, err := zacks.ReadLiveScreen(ctx, def)
+			if err != nil {
+				log.Error().Err(err).Str("Title", def.Title).Msg("could not read saved screen")
+				failed = true
+				continue
+			}
+
+			diff := zacks.DiffScreenDefinition(def, live)
+			if !diff.Empty() {
+				diff.Log()
+				log.Error().Str("Title", def.Title).Msg("saved screen does not match its definition")
+				failed = true
+				continue
+			}
+
+			log.Info().Str("Title", def.Title).Int("Criteria", len(def.Criteria)).Int("Columns", len(def.Columns)).Msg("saved screen matches its definition")
+		}
+
+		if failed {
+			os.Exit(1)
+		}
+	},
+}
+
+// screenDefinitions loads the definition files in args, or the definitions of
+// the configured screens when no files are given
+func screenDefinitions(args []string) []*zacks.ScreenDefinition {
+	defs := make([]*zacks.ScreenDefinition, 0, len(args))
+	for _, fn := range args {
+		def, err := zacks.LoadScreenDefinition(fn)
+		if err != nil {
+			log.Fatal().Err(err).Str("FileName", fn).Msg("invalid screen definition")
+		}
+		defs = append(defs, def)
+	}
+	if len(args) > 0 {
+		return defs
+	}
+
+	screens, err := zacks.ConfiguredScreens()
+	if err != nil {
+		log.Fatal().Err(err).Msg("invalid screen configuration")
+	}
+
+	for _, screen := range screens {
+		if screen.Definition == "" {
+			continue
+		}
+
+		def, err := screen.LoadDefinition()
+		if err != nil {
+			log.Fatal().Err(err).Str("Screen", screen.Name).Msg("invalid screen definition")
+		}
+
+		if screen.Schema == zacks.SchemaRatings {
+			if drift := zacks.DetectColumnDrift(def.Columns); !drift.Empty() {
+				drift.Log()
+				log.Warn().Str("Screen", screen.Name).Str("FileName", screen.Definition).Msg("definition columns do not match ZacksRecord")
+			}
+		}
+		defs = append(defs, def)
+	}
+
+	if len(defs) == 0 {
+		log.Fatal().Msg("no screen definitions; pass definition files or set definition on a [[screens]] entry")
+	}
+	return defs
+}
+
+func init() {
+	screenCmd.AddCommand(screenSyncCmd)
+	screenCmd.AddCommand(screenVerifyCmd)
+
+	rootCmd.AddCommand(screenCmd)
+}
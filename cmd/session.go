@@ -0,0 +1,47 @@
+// Copyright 2022
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"github.com/penny-vault/import-zacks-rank/common"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+)
+
+var sessionCmd = &cobra.Command{
+	Use:   "session",
+	Short: "manage the saved zacks.com browser session",
+	Long: `After a successful login the browser cookies and local storage are saved,
+encrypted with session.key (or the zacks password when it is not set), and
+restored by later runs so they only log in again when the session has expired.`,
+}
+
+var sessionClearCmd = &cobra.Command{
+	Use:   "clear",
+	Args:  cobra.NoArgs,
+	Short: "delete the saved session so the next run logs in again",
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := common.ClearSession(); err != nil {
+			log.Fatal().Err(err).Str("FileName", common.SessionFile()).Msg("could not delete saved session")
+		}
+		log.Info().Str("FileName", common.SessionFile()).Msg("saved session cleared")
+	},
+}
+
+func init() {
+	sessionCmd.AddCommand(sessionClearCmd)
+
+	rootCmd.AddCommand(sessionCmd)
+}
@@ -15,47 +15,41 @@
 package cmd
 
 import (
-	"os"
-	"regexp"
-
 	"github.com/penny-vault/import-zacks-rank/zacks"
 	"github.com/rs/zerolog/log"
 	"github.com/spf13/cobra"
-	"github.com/spf13/viper"
 )
 
 var testCmd = &cobra.Command{
 	Use:   "test",
 	Short: "test downloading zacks ratings",
 	Run: func(cmd *cobra.Command, args []string) {
-		var data []byte
-		var outputFilename string
-		var err error
+		ctx := cmd.Context()
 
-		data, outputFilename, err = zacks.Download()
-		// after multiple retries check if the download succeeded
+		screens, err := zacks.ConfiguredScreens()
 		if err != nil {
-			os.Exit(1)
+			log.Fatal().Err(err).Msg("invalid screen configuration")
 		}
 
-		// parse date from filename :(
-		// if that doesn't work use the current date
-		regex := regexp.MustCompile(`zacks_custom_screen_(\d{4}-\d{2}-\d{2})`)
-		match := regex.FindAllStringSubmatch(outputFilename, -1)
-		var dateStr string
-		if len(match) > 0 {
-			dateStr = match[0][1]
-		} else {
-			log.Error().Str("FileName", outputFilename).Msg("cannot extract date from filename, expecting zacks_custom_screen_YYYY-MM-DD")
-			return
+		// download and parse only; nothing is saved to the DB or uploaded
+		downloadCtx, cancel := downloadContext(ctx)
+		batches, err := (&zacks.ScreensSource{Screens: screens, MaxRetries: 1}).FetchAll(downloadCtx)
+		cancel()
+		if err != nil {
+			log.Fatal().Err(err).Msg("test download failed")
 		}
 
-		ratings := zacks.LoadRatings(data, dateStr, viper.GetInt("limit"))
-		log.Info().Int("NumRatings", len(ratings)).Msg("loaded ratings")
-		if len(ratings) == 0 {
-			log.Fatal().Msg("no ratings returned")
+		// one enricher reads the assets table once for every screen
+		enrich := &zacks.FigiEnricher{}
+		for _, batch := range batches {
+			pipeline := zacks.NewScreenPipeline(batch.Screen, &zacks.BatchSource{Batch: batch})
+			if pipeline.Enrich != nil {
+				pipeline.Enrich = enrich
+			}
+			if _, err := pipeline.Run(ctx); err != nil {
+				log.Fatal().Err(err).Str("Screen", batch.Screen.Name).Msg("test import failed")
+			}
 		}
-		zacks.EnrichWithFigi(ratings)
 	},
 }
 
@@ -0,0 +1,351 @@
+/*
+Copyright 2022
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common
+
+import (
+	"bytes"
+	_ "embed"
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/playwright-community/playwright-go"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/viper"
+)
+
+//go:embed block_rules.toml
+var defaultBlockRules []byte
+
+var ErrInvalidRequestRule = errors.New("invalid request rule")
+
+// resourceTypes are the request resource types playwright reports
+var resourceTypes = map[string]bool{
+	"document": true, "stylesheet": true, "image": true, "media": true, "font": true, "script": true,
+	"texttrack": true, "xhr": true, "fetch": true, "eventsource": true, "websocket": true, "manifest": true, "other": true,
+}
+
+// RequestRule matches requests by domain, URL glob and resource type. Every
+// condition that is set must match; within a condition any entry may match.
+type RequestRule struct {
+	Name          string   `mapstructure:"name"`
+	Domains       []string `mapstructure:"domains"`
+	Globs         []string `mapstructure:"globs"`
+	ResourceTypes []string `mapstructure:"resource_types"`
+
+	globs []*regexp.Regexp
+}
+
+// Matches reports whether the rule matches a request for rawURL, whose host is
+// host, of the given resource type
+func (rule *RequestRule) Matches(rawURL, host, resourceType string) bool {
+	if len(rule.Domains) > 0 && !matchesDomain(host, rule.Domains) {
+		return false
+	}
+
+	if len(rule.globs) > 0 {
+		matched := false
+		for _, glob := range rule.globs {
+			if glob.MatchString(rawURL) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+
+	if len(rule.ResourceTypes) > 0 && !contains(rule.ResourceTypes, resourceType) {
+		return false
+	}
+
+	return true
+}
+
+func (rule *RequestRule) compile() error {
+	if len(rule.Domains) == 0 && len(rule.Globs) == 0 && len(rule.ResourceTypes) == 0 {
+		return fmt.Errorf("%w %s: set domains, globs or resource_types", ErrInvalidRequestRule, rule.Name)
+	}
+
+	for idx, domain := range rule.Domains {
+		rule.Domains[idx] = strings.TrimPrefix(strings.ToLower(strings.TrimSpace(domain)), ".")
+	}
+	for _, resourceType := range rule.ResourceTypes {
+		if !resourceTypes[resourceType] {
+			return fmt.Errorf("%w %s: unknown resource type %q", ErrInvalidRequestRule, rule.Name, resourceType)
+		}
+	}
+
+	rule.globs = make([]*regexp.Regexp, 0, len(rule.Globs))
+	for _, glob := range rule.Globs {
+		rule.globs = append(rule.globs, globRegexp(glob))
+	}
+	return nil
+}
+
+// globRegexp converts a glob, where * matches any run of characters and ? a
+// single character, to an anchored regular expression
+func globRegexp(glob string) *regexp.Regexp {
+	var expr strings.Builder
+	expr.WriteString("^")
+	for _, r := range glob {
+		switch r {
+		case '*':
+			expr.WriteString(".*")
+		case '?':
+			expr.WriteString(".")
+		default:
+			expr.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	expr.WriteString("$")
+	return regexp.MustCompile(expr.String())
+}
+
+// matchesDomain reports whether host is one of domains or a subdomain of one
+func matchesDomain(host string, domains []string) bool {
+	for _, domain := range domains {
+		if host == domain || strings.HasSuffix(host, "."+domain) {
+			return true
+		}
+	}
+	return false
+}
+
+func contains(list []string, needle string) bool {
+	for _, item := range list {
+		if item == needle {
+			return true
+		}
+	}
+	return false
+}
+
+// ruleStats counts the requests a rule decided
+type ruleStats struct {
+	requests int
+	// firstParty counts requests to the site the page is on; a block rule
+	// that matches them may be breaking the site
+	firstParty int
+}
+
+// RequestRules blocks requests that match a block rule unless they also match
+// an allow rule, and counts the requests each rule decided
+type RequestRules struct {
+	Block []*RequestRule `mapstructure:"block"`
+	Allow []*RequestRule `mapstructure:"allow"`
+
+	mu        sync.Mutex
+	stats     map[*RequestRule]*ruleStats
+	unmatched int
+}
+
+// LoadRequestRules reads the rules file named by playwright.block_rules, or
+// the built-in rules when it is not set
+func LoadRequestRules() (*RequestRules, error) {
+	v := viper.New()
+	v.SetConfigType("toml")
+
+	source := "built-in"
+	if fn := viper.GetString("playwright.block_rules"); fn != "" {
+		source = fn
+		v.SetConfigFile(fn)
+		if err := v.ReadInConfig(); err != nil {
+			return nil, fmt.Errorf("could not read request rules %s: %w", fn, err)
+		}
+	} else if err := v.ReadConfig(bytes.NewReader(defaultBlockRules)); err != nil {
+		return nil, fmt.Errorf("could not read built-in request rules: %w", err)
+	}
+
+	rules := &RequestRules{}
+	if err := v.Unmarshal(rules); err != nil {
+		return nil, fmt.Errorf("invalid request rules %s: %w", source, err)
+	}
+
+	names := make(map[string]bool)
+	for _, kind := range []struct {
+		name  string
+		rules []*RequestRule
+	}{{"block", rules.Block}, {"allow", rules.Allow}} {
+		for idx, rule := range kind.rules {
+			if rule.Name == "" {
+				rule.Name = fmt.Sprintf("%s[%d]", kind.name, idx)
+			}
+			if names[rule.Name] {
+				return nil, fmt.Errorf("%w %s: name is used more than once", ErrInvalidRequestRule, rule.Name)
+			}
+			names[rule.Name] = true
+			if err := rule.compile(); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	rules.stats = make(map[*RequestRule]*ruleStats)
+	log.Info().Str("Rules", source).Int("Block", len(rules.Block)).Int("Allow", len(rules.Allow)).Msg("loaded request blocking rules")
+	return rules, nil
+}
+
+// Decide returns the rule that decides a request and whether the request is
+// blocked. The rule is nil when no rule matches; such requests are allowed.
+func (rules *RequestRules) Decide(rawURL, resourceType string) (rule *RequestRule, blocked bool) {
+	host := ""
+	if parsed, err := url.Parse(rawURL); err == nil {
+		host = strings.ToLower(parsed.Hostname())
+	}
+
+	for _, allow := range rules.Allow {
+		if allow.Matches(rawURL, host, resourceType) {
+			return allow, false
+		}
+	}
+	for _, block := range rules.Block {
+		if block.Matches(rawURL, host, resourceType) {
+			return block, true
+		}
+	}
+	return nil, false
+}
+
+// Install routes every request of page through the rules. The counts are
+// logged when the page's browser context closes.
+func (rules *RequestRules) Install(page playwright.Page) error {
+	var once sync.Once
+	page.Context().OnClose(func(playwright.BrowserContext) {
+		once.Do(rules.LogStats)
+	})
+
+	return page.Route("**/*", func(route playwright.Route) {
+		request := route.Request()
+		rule, blocked := rules.Decide(request.URL(), request.ResourceType())
+		rules.count(rule, firstParty(request))
+
+		if blocked {
+			if err := route.Abort("blockedbyclient"); err != nil {
+				log.Error().Err(err).Str("Rule", rule.Name).Msg("failed blocking route")
+			}
+			return
+		}
+
+		if err := route.Continue(); err != nil {
+			log.Debug().Err(err).Msg("could not continue route")
+		}
+	})
+}
+
+func (rules *RequestRules) count(rule *RequestRule, firstParty bool) {
+	rules.mu.Lock()
+	defer rules.mu.Unlock()
+
+	if rule == nil {
+		rules.unmatched++
+		return
+	}
+
+	stats, ok := rules.stats[rule]
+	if !ok {
+		stats = &ruleStats{}
+		rules.stats[rule] = stats
+	}
+	stats.requests++
+	if firstParty {
+		stats.firstParty++
+	}
+}
+
+// LogStats logs how many requests each rule blocked or allowed, and warns
+// about block rules that matched requests to the site being browsed
+func (rules *RequestRules) LogStats() {
+	rules.mu.Lock()
+	defer rules.mu.Unlock()
+
+	logRules := func(list []*RequestRule, action string) int {
+		total := 0
+		sorted := append([]*RequestRule(nil), list...)
+		sort.SliceStable(sorted, func(i, j int) bool {
+			return rules.requests(sorted[i]) > rules.requests(sorted[j])
+		})
+		for _, rule := range sorted {
+			stats, ok := rules.stats[rule]
+			if !ok {
+				continue
+			}
+			total += stats.requests
+
+			event := log.Info()
+			if action == "blocked" && stats.firstParty > 0 {
+				event = log.Warn()
+			}
+			event.Str("Rule", rule.Name).Str("Action", action).Int("Requests", stats.requests).Int("FirstParty", stats.firstParty).Msg("request rule matched")
+		}
+		return total
+	}
+	blocked := logRules(rules.Block, "blocked")
+	allowed := logRules(rules.Allow, "allowed")
+
+	log.Info().Int("Blocked", blocked).Int("Allowed", allowed).Int("Unmatched", rules.unmatched).Msg("request blocking totals")
+}
+
+func (rules *RequestRules) requests(rule *RequestRule) int {
+	if stats, ok := rules.stats[rule]; ok {
+		return stats.requests
+	}
+	return 0
+}
+
+// firstParty reports whether request goes to the site of the top-level
+// document. page.URL() cannot be used because it is still about:blank while
+// the first document and its early subresources load; the main frame's own
+// navigation defines the site, and every other request is compared with the
+// URL of the top frame it was made from.
+func firstParty(request playwright.Request) bool {
+	frame := request.Frame()
+	if frame == nil {
+		return false
+	}
+	if request.IsNavigationRequest() && frame.ParentFrame() == nil {
+		return true
+	}
+	for frame.ParentFrame() != nil {
+		frame = frame.ParentFrame()
+	}
+	return sameSite(request.URL(), frame.URL())
+}
+
+// sameSite reports whether two URLs share their last two host labels, e.g.
+// www.zacks.com and screener-api.zacks.com
+func sameSite(a, b string) bool {
+	siteA, siteB := site(a), site(b)
+	return siteA != "" && siteA == siteB
+}
+
+func site(rawURL string) string {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	labels := strings.Split(strings.ToLower(parsed.Hostname()), ".")
+	if len(labels) < 2 {
+		return strings.Join(labels, ".")
+	}
+	return strings.Join(labels[len(labels)-2:], ".")
+}
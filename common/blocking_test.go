@@ -0,0 +1,136 @@
+/*
+Copyright 2022
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+func TestRequestRulesDecide(t *testing.T) {
+	t.Cleanup(viper.Reset)
+
+	builtin, err := LoadRequestRules()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fn := filepath.Join(t.TempDir(), "rules.toml")
+	custom := `
+[[block]]
+name = "images"
+resource_types = ["image"]
+
+[[block]]
+domains = [".Tracker.example"]
+
+[[allow]]
+name = "zacks-images"
+domains = ["zacks.com"]
+resource_types = ["image"]
+`
+	if err := os.WriteFile(fn, []byte(custom), 0644); err != nil {
+		t.Fatal(err)
+	}
+	viper.Set("playwright.block_rules", fn)
+	configured, err := LoadRequestRules()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name         string
+		rules        *RequestRules
+		url          string
+		resourceType string
+		rule         string
+		blocked      bool
+	}{
+		{"first party", builtin, "https://www.zacks.com/screening/stock-screener", "document", "", false},
+		{"blocked domain", builtin, "https://www.google-analytics.com/analytics.js", "script", "google-tracking", true},
+		{"blocked subdomain", builtin, "https://securepubads.g.doubleclick.net/tag/js/gpt.js", "script", "google-ads", true},
+		{"lookalike domain is not blocked", builtin, "https://notfacebook.com/", "document", "", false},
+		{"glob", builtin, "https://cdn.example.com/prebid.min.js", "script", "prebid", true},
+		{"resource type", configured, "https://cdn.example.com/logo.png", "image", "images", true},
+		{"allow wins over block", configured, "https://staticx.zacks.com/images/logo.png", "image", "zacks-images", false},
+		{"allow needs every condition", configured, "https://www.zacks.com/app.js", "script", "", false},
+		{"domains are normalized", configured, "https://pixel.tracker.example/p.gif", "xhr", "block[1]", true},
+		{"custom file replaces the built-in rules", configured, "https://www.google-analytics.com/analytics.js", "script", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule, blocked := tt.rules.Decide(tt.url, tt.resourceType)
+			name := ""
+			if rule != nil {
+				name = rule.Name
+			}
+			if name != tt.rule || blocked != tt.blocked {
+				t.Errorf("expected rule %q (blocked=%v), got %q (blocked=%v)", tt.rule, tt.blocked, name, blocked)
+			}
+		})
+	}
+}
+
+func TestLoadRequestRulesRejects(t *testing.T) {
+	t.Cleanup(viper.Reset)
+
+	tests := []struct {
+		name  string
+		rules string
+	}{
+		{"rule without conditions", "[[block]]\nname = \"empty\"\n"},
+		{"unknown resource type", "[[block]]\nresource_types = [\"video\"]\n"},
+		{"duplicate name", "[[block]]\nname = \"a\"\nglobs = [\"*\"]\n\n[[allow]]\nname = \"a\"\nglobs = [\"*\"]\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fn := filepath.Join(t.TempDir(), "rules.toml")
+			if err := os.WriteFile(fn, []byte(tt.rules), 0644); err != nil {
+				t.Fatal(err)
+			}
+			viper.Set("playwright.block_rules", fn)
+
+			if _, err := LoadRequestRules(); !errors.Is(err, ErrInvalidRequestRule) {
+				t.Errorf("expected %v, got %v", ErrInvalidRequestRule, err)
+			}
+		})
+	}
+}
+
+func TestSameSite(t *testing.T) {
+	tests := []struct {
+		a, b string
+		same bool
+	}{
+		{"https://www.zacks.com/", "https://screener-api.zacks.com/export", true},
+		{"https://zacks.com/", "https://WWW.ZACKS.COM/", true},
+		{"https://www.zacks.com/", "https://www.google.com/", false},
+		{"about:blank", "about:blank", false},
+	}
+
+	for _, tt := range tests {
+		if same := sameSite(tt.a, tt.b); same != tt.same {
+			t.Errorf("sameSite(%s, %s) = %v, expected %v", tt.a, tt.b, same, tt.same)
+		}
+	}
+}
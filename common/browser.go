@@ -0,0 +1,145 @@
+/*
+Copyright 2022
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/playwright-community/playwright-go"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/viper"
+)
+
+// Browser engines
+const (
+	EngineChromium = "chromium"
+	EngineFirefox  = "firefox"
+	EngineWebKit   = "webkit"
+)
+
+// How StartPlaywright gets a browser
+const (
+	// ModeLaunch starts a local browser
+	ModeLaunch = "launch"
+	// ModeConnect attaches to a playwright browser server over WebSocket
+	ModeConnect = "connect"
+	// ModeCDP attaches to a Chromium over the Chrome DevTools Protocol
+	ModeCDP = "cdp"
+)
+
+var (
+	ErrUnknownEngine      = errors.New("unknown browser engine; expected chromium, firefox or webkit")
+	ErrUnknownBrowserMode = errors.New("unknown browser mode; expected launch, connect or cdp")
+	ErrNoBrowserEndpoint  = errors.New("playwright.endpoint must be set to connect to a remote browser")
+	ErrCDPNeedsChromium   = errors.New("only chromium can be connected to over CDP")
+)
+
+// BrowserOptions selects the browser StartPlaywright uses and how it is reached
+type BrowserOptions struct {
+	Engine string
+	Mode   string
+	// Endpoint is the ws:// or http:// URL of the remote browser in connect and cdp mode
+	Endpoint string
+	// Args are extra command line arguments for a launched browser
+	Args []string
+	// ExecutablePath overrides the browser binary playwright installed
+	ExecutablePath string
+	Headless       bool
+}
+
+// ConfiguredBrowser reads playwright.browser, playwright.mode,
+// playwright.endpoint, playwright.args and playwright.executable_path
+func ConfiguredBrowser(headless bool) (*BrowserOptions, error) {
+	options := &BrowserOptions{
+		Engine:         viper.GetString("playwright.browser"),
+		Mode:           viper.GetString("playwright.mode"),
+		Endpoint:       viper.GetString("playwright.endpoint"),
+		Args:           viper.GetStringSlice("playwright.args"),
+		ExecutablePath: viper.GetString("playwright.executable_path"),
+		Headless:       headless,
+	}
+
+	if options.Engine == "" {
+		options.Engine = EngineChromium
+	}
+	if options.Mode == "" {
+		options.Mode = ModeLaunch
+	}
+
+	switch options.Engine {
+	case EngineChromium, EngineFirefox, EngineWebKit:
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnknownEngine, options.Engine)
+	}
+
+	switch options.Mode {
+	case ModeLaunch:
+	case ModeConnect, ModeCDP:
+		if options.Endpoint == "" {
+			return nil, ErrNoBrowserEndpoint
+		}
+		if options.Mode == ModeCDP && options.Engine != EngineChromium {
+			return nil, ErrCDPNeedsChromium
+		}
+		if len(options.Args) > 0 || options.ExecutablePath != "" {
+			log.Warn().Str("Mode", options.Mode).Msg("playwright.args and playwright.executable_path only apply when launching a browser")
+		}
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnknownBrowserMode, options.Mode)
+	}
+
+	return options, nil
+}
+
+// browserType returns the playwright browser type of the engine
+func (options *BrowserOptions) browserType(pw *playwright.Playwright) playwright.BrowserType {
+	switch options.Engine {
+	case EngineFirefox:
+		return pw.Firefox
+	case EngineWebKit:
+		return pw.WebKit
+	default:
+		return pw.Chromium
+	}
+}
+
+// Open launches or connects to the browser
+func (options *BrowserOptions) Open(pw *playwright.Playwright) (playwright.Browser, error) {
+	browserType := options.browserType(pw)
+
+	switch options.Mode {
+	case ModeConnect:
+		log.Info().Str("Engine", options.Engine).Str("Endpoint", options.Endpoint).Msg("connecting to remote browser")
+		return browserType.Connect(options.Endpoint)
+	case ModeCDP:
+		log.Info().Str("Engine", options.Engine).Str("Endpoint", options.Endpoint).Msg("connecting to remote browser over CDP")
+		return browserType.ConnectOverCDP(options.Endpoint)
+	default:
+		launchOptions := playwright.BrowserTypeLaunchOptions{
+			Headless: playwright.Bool(options.Headless),
+			Args:     options.Args,
+		}
+		executablePath := browserType.ExecutablePath()
+		if options.ExecutablePath != "" {
+			launchOptions.ExecutablePath = playwright.String(options.ExecutablePath)
+			executablePath = options.ExecutablePath
+		}
+		log.Info().Str("Engine", options.Engine).Bool("Headless", options.Headless).Str("ExecutablePath", executablePath).Strs("Args", options.Args).Msg("launching browser")
+		return browserType.Launch(launchOptions)
+	}
+}
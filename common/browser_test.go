@@ -0,0 +1,68 @@
+/*
+Copyright 2022
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+func TestConfiguredBrowser(t *testing.T) {
+	t.Cleanup(viper.Reset)
+
+	tests := []struct {
+		name   string
+		config map[string]any
+		engine string
+		mode   string
+		err    error
+	}{
+		{"defaults", nil, EngineChromium, ModeLaunch, nil},
+		{"firefox", map[string]any{"playwright.browser": "firefox"}, EngineFirefox, ModeLaunch, nil},
+		{"connect", map[string]any{"playwright.browser": "webkit", "playwright.mode": "connect", "playwright.endpoint": "ws://browser:3000/"}, EngineWebKit, ModeConnect, nil},
+		{"cdp", map[string]any{"playwright.mode": "cdp", "playwright.endpoint": "http://chrome:9222"}, EngineChromium, ModeCDP, nil},
+		{"unknown engine", map[string]any{"playwright.browser": "edge"}, "", "", ErrUnknownEngine},
+		{"unknown mode", map[string]any{"playwright.mode": "attach"}, "", "", ErrUnknownBrowserMode},
+		{"connect without endpoint", map[string]any{"playwright.mode": "connect"}, "", "", ErrNoBrowserEndpoint},
+		{"cdp with firefox", map[string]any{"playwright.browser": "firefox", "playwright.mode": "cdp", "playwright.endpoint": "http://firefox:9222"}, "", "", ErrCDPNeedsChromium},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			viper.Reset()
+			for key, value := range tt.config {
+				viper.Set(key, value)
+			}
+
+			options, err := ConfiguredBrowser(true)
+			if tt.err != nil {
+				if !errors.Is(err, tt.err) {
+					t.Fatalf("expected %v, got %v", tt.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if options.Engine != tt.engine || options.Mode != tt.mode || !options.Headless {
+				t.Errorf("expected headless %s in %s mode, got %+v", tt.engine, tt.mode, options)
+			}
+		})
+	}
+}
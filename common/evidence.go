@@ -0,0 +1,194 @@
+/*
+Copyright 2022
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/playwright-community/playwright-go"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/viper"
+)
+
+// maxConsoleLines is how many of the most recent console messages are kept
+const maxConsoleLines = 2000
+
+var unsafeFilenameRegex = regexp.MustCompile(`[^A-Za-z0-9_-]+`)
+
+// Evidence records what a page did so that a failed step can be diagnosed
+// afterwards. It collects the console output and, once StartTrace is called,
+// keeps a playwright trace running on the page's context; Capture writes them
+// to disk together with a screenshot and the HTML of the page and its frames.
+//
+// A bundle shows whatever the page showed and sent, so it may hold account
+// details, and its trace records the session cookies of every request. Treat
+// bundles as credentials.
+type Evidence struct {
+	page    playwright.Page
+	tracing bool
+
+	mu      sync.Mutex
+	console []string
+}
+
+// EvidenceDir is the directory evidence bundles are written to; empty when
+// evidence collection is turned off
+func EvidenceDir() string {
+	return viper.GetString("evidence.dir")
+}
+
+// RecordEvidence starts recording the console of page. It returns nil, which
+// is safe to use, when evidence.dir is empty.
+func RecordEvidence(page playwright.Page) *Evidence {
+	if EvidenceDir() == "" || page == nil {
+		return nil
+	}
+
+	evidence := &Evidence{page: page}
+
+	page.OnConsole(func(msg playwright.ConsoleMessage) {
+		line := fmt.Sprintf("%s [%s] %s", time.Now().Format(time.RFC3339), msg.Type(), msg.Text())
+		if location := msg.Location(); location != nil && location.URL != "" {
+			line += fmt.Sprintf(" (%s:%d)", location.URL, location.LineNumber)
+		}
+		evidence.log(line)
+	})
+	page.OnPageError(func(err error) {
+		evidence.log(fmt.Sprintf("%s [pageerror] %s", time.Now().Format(time.RFC3339), err))
+	})
+
+	return evidence
+}
+
+// StartTrace starts the playwright trace that later captures include. Call
+// it only after logging in: a trace taken during login would record the
+// password as it is typed.
+func (evidence *Evidence) StartTrace() {
+	if evidence == nil || evidence.tracing {
+		return
+	}
+
+	if err := evidence.page.Context().Tracing().Start(playwright.TracingStartOptions{
+		Screenshots: playwright.Bool(true),
+		Snapshots:   playwright.Bool(true),
+	}); err != nil {
+		log.Warn().Err(err).Msg("could not start playwright tracing; evidence will not include a trace")
+		return
+	}
+	evidence.tracing = true
+}
+
+func (evidence *Evidence) log(line string) {
+	evidence.mu.Lock()
+	defer evidence.mu.Unlock()
+
+	evidence.console = append(evidence.console, line)
+	if len(evidence.console) > maxConsoleLines {
+		evidence.console = evidence.console[len(evidence.console)-maxConsoleLines:]
+	}
+}
+
+// Capture writes an evidence bundle for step, which failed with cause, to a
+// new directory under EvidenceDir and returns its path. The bundle holds
+// error.txt, screenshot.png, page.html, frame-N.html for every frame, frames.txt
+// listing them, console.log and, when StartTrace was called, trace.zip with the
+// playwright trace since the previous capture. Parts that cannot be captured are skipped and reported in
+// the returned error. A nil Evidence captures nothing.
+func (evidence *Evidence) Capture(step string, cause error) (string, error) {
+	if evidence == nil {
+		return "", nil
+	}
+
+	name := fmt.Sprintf("%s-%s", time.Now().UTC().Format("20060102T150405.000Z"), strings.Trim(unsafeFilenameRegex.ReplaceAllString(step, "_"), "_"))
+	dir := filepath.Join(EvidenceDir(), name)
+	// the bundle holds session cookies, so only the current user may read it;
+	// the screenshot and trace the driver writes are protected by the directory
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		log.Error().Err(err).Str("Dir", dir).Msg("could not create evidence directory")
+		return "", err
+	}
+
+	var errs []error
+	write := func(fn string, data []byte) {
+		if err := os.WriteFile(filepath.Join(dir, fn), data, 0600); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", fn, err))
+		}
+	}
+
+	write("error.txt", []byte(fmt.Sprintf("step: %s\nerror: %v\nurl: %s\ntime: %s\n", step, cause, evidence.page.URL(), time.Now().Format(time.RFC3339))))
+
+	if _, err := evidence.page.Screenshot(playwright.PageScreenshotOptions{
+		Path:     playwright.String(filepath.Join(dir, "screenshot.png")),
+		FullPage: playwright.Bool(true),
+	}); err != nil {
+		errs = append(errs, fmt.Errorf("screenshot.png: %w", err))
+	}
+
+	if html, err := evidence.page.Content(); err != nil {
+		errs = append(errs, fmt.Errorf("page.html: %w", err))
+	} else {
+		write("page.html", []byte(html))
+	}
+
+	var frames strings.Builder
+	for idx, frame := range evidence.page.Frames() {
+		if frame == evidence.page.MainFrame() {
+			continue
+		}
+		fn := fmt.Sprintf("frame-%d.html", idx)
+		fmt.Fprintf(&frames, "%s\t%s\t%s\n", fn, frame.Name(), frame.URL())
+		if html, err := frame.Content(); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", fn, err))
+		} else {
+			write(fn, []byte(html))
+		}
+	}
+	write("frames.txt", []byte(frames.String()))
+
+	evidence.mu.Lock()
+	console := strings.Join(evidence.console, "\n")
+	evidence.mu.Unlock()
+	write("console.log", []byte(console))
+
+	if evidence.tracing {
+		// end the current trace chunk and begin another so a later failure on
+		// the same page gets a trace of its own
+		tracing := evidence.page.Context().Tracing()
+		if err := tracing.StopChunk(filepath.Join(dir, "trace.zip")); err != nil {
+			errs = append(errs, fmt.Errorf("trace.zip: %w", err))
+		}
+		if err := tracing.StartChunk(); err != nil {
+			log.Warn().Err(err).Msg("could not restart playwright tracing")
+			evidence.tracing = false
+		}
+	}
+
+	err := errors.Join(errs...)
+	if err != nil {
+		log.Warn().Err(err).Str("Dir", dir).Msg("evidence bundle is incomplete")
+	}
+	log.Info().Str("Step", step).Str("Dir", dir).Msg("saved failure evidence")
+
+	return dir, err
+}
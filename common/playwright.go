@@ -17,7 +17,12 @@ limitations under the License.
 package common
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
 	"strings"
+	"sync"
 
 	"github.com/go-rod/stealth"
 	"github.com/playwright-community/playwright-go"
@@ -25,11 +30,15 @@ import (
 	"github.com/spf13/viper"
 )
 
+// ErrNoPage is returned by StartPlaywright when the browser did not open a page
+var ErrNoPage = errors.New("could not create browser page")
+
 // StealthPage creates a new playwright page with stealth js loaded to prevent bot detection
 func StealthPage(context *playwright.BrowserContext) playwright.Page {
 	page, err := (*context).NewPage()
 	if err != nil {
 		log.Error().Err(err).Msg("could not create page")
+		return nil
 	}
 
 	if err = page.AddInitScript(playwright.Script{
@@ -41,133 +50,204 @@ func StealthPage(context *playwright.BrowserContext) playwright.Page {
 	return page
 }
 
-// BuildUserAgent dynamically determines the user agent and removes the headless identifier
+// BuildUserAgent derives the user agent of the desktop release of browser from
+// its version, without the headless identifier, so no page has to be loaded
+// to find it. Chrome reports only its major version in the user agent. The
+// platform is that of this host, so it only fits locally launched browsers;
+// use RemoteUserAgent for the others.
 func BuildUserAgent(browser *playwright.Browser) string {
-	context, err := (*browser).NewContext()
-	if err != nil {
-		log.Error().Err(err).Msg("could not create context for building user agent")
+	version := (*browser).Version()
+	major, _, _ := strings.Cut(version, ".")
+
+	switch (*browser).BrowserType().Name() {
+	case EngineFirefox:
+		platform := userAgentPlatform()
+		if runtime.GOOS == "darwin" {
+			platform = "Macintosh; Intel Mac OS X 10.15"
+		}
+		return fmt.Sprintf("Mozilla/5.0 (%s; rv:%s.0) Gecko/20100101 Firefox/%s.0", platform, major, major)
+	case EngineWebKit:
+		return fmt.Sprintf("Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/%s Safari/605.1.15", version)
+	default:
+		return fmt.Sprintf("Mozilla/5.0 (%s) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/%s.0.0.0 Safari/537.36", userAgentPlatform(), major)
 	}
-	defer context.Close()
+}
 
-	page, err := context.NewPage()
+// RemoteUserAgent asks browser for its own user agent from a blank page, so
+// its platform matches the navigator.platform of the host the browser runs
+// on, and drops the headless identifier
+func RemoteUserAgent(browser playwright.Browser) (string, error) {
+	probe, err := browser.NewContext()
 	if err != nil {
-		log.Error().Err(err).Msg("could not create page BuildUserAgent")
+		return "", err
 	}
+	defer probe.Close()
 
-	resp, err := page.Goto("https://playwright.dev", playwright.PageGotoOptions{
-		WaitUntil: playwright.WaitUntilStateNetworkidle,
-	})
+	page, err := probe.NewPage()
 	if err != nil {
-		log.Error().Err(err).Str("Url", "https://playwright.dev").Msg("could not load page")
+		return "", err
 	}
 
-	headers, err := resp.Request().AllHeaders()
+	userAgent, err := page.Evaluate("() => navigator.userAgent")
 	if err != nil {
-		log.Error().Err(err).Msg("could not load request headers")
+		return "", err
 	}
 
-	userAgent := headers["user-agent"]
-	userAgent = strings.Replace(userAgent, "Headless", "", -1)
-	return userAgent
+	ua, ok := userAgent.(string)
+	if !ok || ua == "" {
+		return "", fmt.Errorf("browser reported user agent %v", userAgent)
+	}
+	return strings.Replace(ua, "HeadlessChrome/", "Chrome/", 1), nil
+}
+
+// userAgentPlatform is the platform token of the host, matching what
+// navigator.platform reports
+func userAgentPlatform() string {
+	switch runtime.GOOS {
+	case "windows":
+		return "Windows NT 10.0; Win64; x64"
+	case "darwin":
+		return "Macintosh; Intel Mac OS X 10_15_7"
+	default:
+		return "X11; Linux x86_64"
+	}
 }
 
-// StartPlaywright starts the playwright server and browser, it then creates a new context and page with the stealth extensions loaded
-func StartPlaywright(headless bool) (page playwright.Page, context playwright.BrowserContext, browser playwright.Browser, pw *playwright.Playwright) {
-	pw, err := playwright.Run()
+// StartPlaywright starts the playwright server and launches or connects to the browser selected by ConfiguredBrowser, it then
+// creates a new context and page with the stealth extensions loaded.
+// If any step fails everything started so far is stopped and the error is returned.
+func StartPlaywright(headless bool) (page playwright.Page, context playwright.BrowserContext, browser playwright.Browser, pw *playwright.Playwright, err error) {
+	options, err := ConfiguredBrowser(headless)
+	if err != nil {
+		log.Error().Err(err).Msg("invalid browser configuration")
+		return nil, nil, nil, nil, err
+	}
+
+	profile, err := SelectProfile()
+	if err != nil {
+		log.Error().Err(err).Msg("invalid browser profile")
+		return nil, nil, nil, nil, err
+	}
+
+	requestRules, err := LoadRequestRules()
+	if err != nil {
+		log.Error().Err(err).Msg("invalid request blocking rules")
+		return nil, nil, nil, nil, err
+	}
+
+	pw, err = playwright.Run()
 	if err != nil {
 		log.Error().Err(err).Msg("could not launch playwright")
+		return nil, nil, nil, nil, err
 	}
 
-	browser, err = pw.Chromium.Launch(playwright.BrowserTypeLaunchOptions{
-		Headless: playwright.Bool(headless),
-	})
+	browser, err = options.Open(pw)
 	if err != nil {
-		log.Error().Err(err).Msg("could not launch Chromium")
+		log.Error().Err(err).Str("Engine", options.Engine).Str("Mode", options.Mode).Msg("could not open browser")
+		StopPlaywright(nil, nil, nil, pw)
+		return nil, nil, nil, nil, err
 	}
 
-	log.Info().Bool("Headless", headless).Str("ExecutablePath", pw.Chromium.ExecutablePath()).Str("BrowserVersion", browser.Version()).Msg("starting playwright")
+	log.Info().Str("Engine", options.Engine).Str("Mode", options.Mode).Str("BrowserVersion", browser.Version()).Msg("starting playwright")
 
-	// calculate user-agent
+	// calculate user-agent; the profile's user agent takes precedence. A
+	// remote browser runs on another host whose platform is only known to
+	// the browser, so it is asked rather than guessed from this one.
 	userAgent := viper.GetString("user_agent")
-	if userAgent == "" {
+	switch {
+	case userAgent != "" || (profile != nil && profile.UserAgent != ""):
+	case options.Mode == ModeLaunch:
 		userAgent = BuildUserAgent(&browser)
+	default:
+		if userAgent, err = RemoteUserAgent(browser); err != nil {
+			log.Error().Err(err).Str("Mode", options.Mode).Msg("could not read the user agent of the remote browser; set user_agent in the browser profile")
+			StopPlaywright(nil, nil, browser, pw)
+			return nil, nil, nil, nil, err
+		}
 	}
-	log.Info().Str("UserAgent", userAgent).Msg("using user-agent")
 
-	// create context
-	context, err = browser.NewContext(playwright.BrowserNewContextOptions{
+	contextOptions := playwright.BrowserNewContextOptions{
 		UserAgent: playwright.String(userAgent),
-	})
+	}
+	profile.Apply(&contextOptions)
+	profile.Log()
+	log.Info().Str("UserAgent", *contextOptions.UserAgent).Msg("using user-agent")
+
+	// reuse the cookies of the last successful login
+	if state, err := LoadSession(); err != nil {
+		if !errors.Is(err, ErrSessionsDisabled) {
+			log.Warn().Err(err).Str("FileName", SessionFile()).Msg("could not load saved browser session; starting a new one")
+		}
+	} else if state != nil {
+		log.Info().Str("FileName", SessionFile()).Msg("restoring saved browser session")
+		contextOptions.StorageState = state
+	}
+
+	// create context
+	context, err = browser.NewContext(contextOptions)
 	if err != nil {
-		log.Error().Msg("could not create browser context")
+		log.Error().Err(err).Msg("could not create browser context")
+		StopPlaywright(nil, nil, browser, pw)
+		return nil, nil, nil, nil, err
 	}
 
-	// get a page
-	page = StealthPage(&context)
+	// get a page; the stealth script imitates Chrome so other engines get a plain page
+	if options.Engine == EngineChromium {
+		page = StealthPage(&context)
+	} else if page, err = context.NewPage(); err != nil {
+		log.Error().Err(err).Msg("could not create page")
+		page = nil
+	}
+	if page == nil {
+		StopPlaywright(nil, context, browser, pw)
+		return nil, nil, nil, nil, ErrNoPage
+	}
 
-	// block trackers
-	BlockTrackers(page)
+	// block trackers and ads
+	if err = requestRules.Install(page); err != nil {
+		log.Error().Err(err).Msg("could not install request blocking rules")
+		StopPlaywright(nil, context, browser, pw)
+		return nil, nil, nil, nil, err
+	}
 
 	return
 }
 
-func BlockTrackers(page playwright.Page) {
-	// block a variety of domains that contain trackers and ads
-	page.Route("**/*", func(route playwright.Route) {
-		request := route.Request()
-		if strings.Contains(request.URL(), "google.com") ||
-			strings.Contains(request.URL(), "googletagservices.com") ||
-			strings.Contains(request.URL(), "googlesyndication.com") ||
-			strings.Contains(request.URL(), "facebook.com") ||
-			strings.Contains(request.URL(), "moatpixel.com") ||
-			strings.Contains(request.URL(), "moatads.com") ||
-			strings.Contains(request.URL(), "adsystem.com") ||
-			strings.Contains(request.URL(), "connatix.com") ||
-			strings.Contains(request.URL(), "prebid") ||
-			strings.Contains(request.URL(), "sodar") ||
-			strings.Contains(request.URL(), "auction") ||
-			strings.Contains(request.URL(), "rubiconproject.com") ||
-			strings.Contains(request.URL(), "pubmatic.com") ||
-			strings.Contains(request.URL(), "amazon-adsystem.com") ||
-			strings.Contains(request.URL(), "adnxs.com") ||
-			strings.Contains(request.URL(), "lijit.com") ||
-			strings.Contains(request.URL(), "3lift.com") ||
-			strings.Contains(request.URL(), "doubleclick.net") ||
-			strings.Contains(request.URL(), "bidswitch.net") ||
-			strings.Contains(request.URL(), "casalemedia.com") ||
-			strings.Contains(request.URL(), "yahoo.com") ||
-			strings.Contains(request.URL(), "sitescout.com") ||
-			strings.Contains(request.URL(), "ipredictive.com") ||
-			strings.Contains(request.URL(), "uat5-b.investingchannel.com") ||
-			strings.Contains(request.URL(), "eyeota.net") {
-			err := route.Abort("failed")
-			if err != nil {
-				log.Error().Err(err).Msg("failed blocking route")
+// CloseOnDone closes browser as soon as ctx is done so that a playwright call
+// that is waiting on the page returns instead of running to its own timeout.
+// Call the returned function once the browser is no longer in use.
+func CloseOnDone(ctx context.Context, browser playwright.Browser) (stop func()) {
+	done := make(chan struct{})
+	go func() {
+		select {
+		case <-ctx.Done():
+			log.Warn().Err(ctx.Err()).Msg("closing browser early")
+			if err := browser.Close(); err != nil {
+				log.Error().Err(err).Msg("error encountered when closing browser")
 			}
-			return
+		case <-done:
 		}
+	}()
 
-		/*
-			if request.ResourceType() == "image" {
-				err := route.Abort("failed")
-				if err != nil {
-					log.Error().Err(err).Msg("failed blocking image")
-				}
-			}
-		*/
-
-		route.Continue()
-	})
+	var once sync.Once
+	return func() { once.Do(func() { close(done) }) }
 }
 
+// StopPlaywright closes the browser and stops the playwright driver. It is
+// safe to call with the values of a StartPlaywright that failed part way.
 func StopPlaywright(page playwright.Page, context playwright.BrowserContext, browser playwright.Browser, pw *playwright.Playwright) {
-	log.Info().Msg("closing browser")
-	if err := browser.Close(); err != nil {
-		log.Error().Err(err).Msg("error encountered when closing browser")
+	// the browser may already have been closed by CloseOnDone
+	if browser != nil && browser.IsConnected() {
+		log.Info().Msg("closing browser")
+		if err := browser.Close(); err != nil {
+			log.Error().Err(err).Msg("error encountered when closing browser")
+		}
 	}
 
-	log.Info().Msg("stopping playwright")
-	if err := pw.Stop(); err != nil {
-		log.Error().Err(err).Msg("error encountered when stopping playwright")
+	if pw != nil {
+		log.Info().Msg("stopping playwright")
+		if err := pw.Stop(); err != nil {
+			log.Error().Err(err).Msg("error encountered when stopping playwright")
+		}
 	}
 }
@@ -0,0 +1,224 @@
+/*
+Copyright 2022
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common
+
+import (
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/playwright-community/playwright-go"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/viper"
+)
+
+// ProfileRotate as playwright.profile uses a different profile for each browser
+// started, beginning with a random one
+const ProfileRotate = "rotate"
+
+var ErrUnknownProfile = errors.New("no browser profile with that name is configured")
+
+// Viewport is the size of the browser window in CSS pixels
+type Viewport struct {
+	Width  int `mapstructure:"width"`
+	Height int `mapstructure:"height"`
+}
+
+// ProxyConfig routes the browser's traffic through an HTTP or SOCKS proxy
+type ProxyConfig struct {
+	// Server is e.g. http://proxy:3128 or socks5://proxy:1080
+	Server string `mapstructure:"server"`
+	// Bypass is a comma separated list of domains not to proxy
+	Bypass string `mapstructure:"bypass"`
+	// Username and Password authenticate with an HTTP proxy; the browsers do
+	// not support SOCKS authentication
+	Username string `mapstructure:"username"`
+	Password string `mapstructure:"password"`
+}
+
+// Profile is a browser fingerprint: the user agent, window size, locale and
+// timezone the site sees, and the proxy it sees them from. Empty fields keep
+// the browser's defaults.
+type Profile struct {
+	Name           string       `mapstructure:"name"`
+	UserAgent      string       `mapstructure:"user_agent"`
+	Viewport       *Viewport    `mapstructure:"viewport"`
+	Locale         string       `mapstructure:"locale"`
+	Timezone       string       `mapstructure:"timezone"`
+	AcceptLanguage string       `mapstructure:"accept_language"`
+	Proxy          *ProxyConfig `mapstructure:"proxy"`
+}
+
+// ConfiguredProfiles returns the profiles listed under [[profiles]]
+func ConfiguredProfiles() ([]*Profile, error) {
+	var profiles []*Profile
+	if err := viper.UnmarshalKey("profiles", &profiles); err != nil {
+		return nil, fmt.Errorf("invalid profiles: %w", err)
+	}
+
+	names := make(map[string]bool, len(profiles))
+	for idx, profile := range profiles {
+		if profile.Name == "" {
+			return nil, fmt.Errorf("profiles[%d]: name is required", idx)
+		}
+		if profile.Name == ProfileRotate {
+			return nil, fmt.Errorf("profile %s: the name is reserved for rotating through the profiles", profile.Name)
+		}
+		if names[profile.Name] {
+			return nil, fmt.Errorf("profile %s: name is used more than once", profile.Name)
+		}
+		names[profile.Name] = true
+
+		if profile.Viewport != nil && (profile.Viewport.Width <= 0 || profile.Viewport.Height <= 0) {
+			return nil, fmt.Errorf("profile %s: viewport width and height must be positive", profile.Name)
+		}
+		if profile.Timezone != "" {
+			if _, err := time.LoadLocation(profile.Timezone); err != nil {
+				return nil, fmt.Errorf("profile %s: unknown timezone %q", profile.Name, profile.Timezone)
+			}
+		}
+		if profile.Proxy != nil {
+			if profile.Proxy.Server == "" {
+				return nil, fmt.Errorf("profile %s: proxy.server is required", profile.Name)
+			}
+			if (profile.Proxy.Username == "") != (profile.Proxy.Password == "") {
+				return nil, fmt.Errorf("profile %s: proxy.username and proxy.password must be set together", profile.Name)
+			}
+			// the browsers cannot authenticate with a SOCKS proxy; catch it before one is started
+			if profile.Proxy.Username != "" && strings.HasPrefix(strings.ToLower(profile.Proxy.Server), "socks") {
+				return nil, fmt.Errorf("profile %s: proxy.username and proxy.password are only supported for HTTP proxies", profile.Name)
+			}
+		}
+	}
+
+	return profiles, nil
+}
+
+var (
+	rotationMu   sync.Mutex
+	rotationNext = -1
+)
+
+// SelectProfile returns the profile named by playwright.profile. An empty
+// name selects the first configured profile, or nil when there are none, and
+// ProfileRotate selects the next profile in turn.
+func SelectProfile() (*Profile, error) {
+	profiles, err := ConfiguredProfiles()
+	if err != nil {
+		return nil, err
+	}
+
+	name := viper.GetString("playwright.profile")
+	switch {
+	case len(profiles) == 0 && (name == "" || name == ProfileRotate):
+		return nil, nil
+	case name == "":
+		return profiles[0], nil
+	case name == ProfileRotate:
+		rotationMu.Lock()
+		defer rotationMu.Unlock()
+		if rotationNext < 0 {
+			rotationNext = rand.IntN(len(profiles))
+		}
+		profile := profiles[rotationNext%len(profiles)]
+		rotationNext++
+		return profile, nil
+	}
+
+	for _, profile := range profiles {
+		if profile.Name == name {
+			return profile, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %q", ErrUnknownProfile, name)
+}
+
+// Apply sets the profile's fingerprint and proxy on options. A nil profile
+// changes nothing.
+func (profile *Profile) Apply(options *playwright.BrowserNewContextOptions) {
+	if profile == nil {
+		return
+	}
+
+	if profile.UserAgent != "" {
+		options.UserAgent = playwright.String(profile.UserAgent)
+	}
+	if profile.Viewport != nil {
+		options.Viewport = &playwright.Size{Width: profile.Viewport.Width, Height: profile.Viewport.Height}
+	}
+	if profile.Locale != "" {
+		options.Locale = playwright.String(profile.Locale)
+	}
+	if profile.Timezone != "" {
+		options.TimezoneId = playwright.String(profile.Timezone)
+	}
+	if profile.AcceptLanguage != "" {
+		if options.ExtraHttpHeaders == nil {
+			options.ExtraHttpHeaders = make(map[string]string)
+		}
+		options.ExtraHttpHeaders["Accept-Language"] = profile.AcceptLanguage
+	}
+	if proxy := profile.Proxy; proxy != nil {
+		options.Proxy = &playwright.Proxy{Server: proxy.Server}
+		if proxy.Bypass != "" {
+			options.Proxy.Bypass = playwright.String(proxy.Bypass)
+		}
+		if proxy.Username != "" {
+			options.Proxy.Username = playwright.String(proxy.Username)
+			options.Proxy.Password = playwright.String(proxy.Password)
+		}
+	}
+}
+
+// Log reports which profile is used without its proxy credentials
+func (profile *Profile) Log() {
+	if profile == nil {
+		return
+	}
+
+	event := log.Info().Str("Profile", profile.Name)
+	if profile.Viewport != nil {
+		event = event.Str("Viewport", fmt.Sprintf("%dx%d", profile.Viewport.Width, profile.Viewport.Height))
+	}
+	if profile.Locale != "" {
+		event = event.Str("Locale", profile.Locale)
+	}
+	if profile.Timezone != "" {
+		event = event.Str("Timezone", profile.Timezone)
+	}
+	if profile.Proxy != nil {
+		event = event.Str("Proxy", redactProxy(profile.Proxy.Server))
+	}
+	event.Msg("using browser profile")
+}
+
+// redactProxy drops any credentials embedded in a proxy URL
+func redactProxy(server string) string {
+	if !strings.Contains(server, "@") {
+		return server
+	}
+	parsed, err := url.Parse(server)
+	if err != nil || parsed.User == nil {
+		return "<redacted>"
+	}
+	parsed.User = nil
+	return parsed.String()
+}
@@ -0,0 +1,125 @@
+/*
+Copyright 2022
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+func TestSelectProfile(t *testing.T) {
+	t.Cleanup(viper.Reset)
+
+	profiles := []map[string]any{
+		{"name": "desktop", "viewport": map[string]any{"width": 1920, "height": 1080}},
+		{"name": "laptop", "timezone": "America/Chicago"},
+	}
+
+	tests := []struct {
+		name     string
+		profiles []map[string]any
+		selected string
+		want     string
+		err      error
+	}{
+		{"no profiles", nil, "", "", nil},
+		{"no profiles to rotate", nil, ProfileRotate, "", nil},
+		{"first profile by default", profiles, "", "desktop", nil},
+		{"by name", profiles, "laptop", "laptop", nil},
+		{"unknown name", profiles, "tablet", "", ErrUnknownProfile},
+		{"unknown name without profiles", nil, "tablet", "", ErrUnknownProfile},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			viper.Reset()
+			viper.Set("profiles", tt.profiles)
+			viper.Set("playwright.profile", tt.selected)
+
+			profile, err := SelectProfile()
+			if tt.err != nil {
+				if !errors.Is(err, tt.err) {
+					t.Fatalf("expected %v, got %v", tt.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			name := ""
+			if profile != nil {
+				name = profile.Name
+			}
+			if name != tt.want {
+				t.Errorf("expected profile %q, got %q", tt.want, name)
+			}
+		})
+	}
+}
+
+func TestSelectProfileRotates(t *testing.T) {
+	t.Cleanup(viper.Reset)
+	viper.Set("profiles", []map[string]any{{"name": "a"}, {"name": "b"}, {"name": "c"}})
+	viper.Set("playwright.profile", ProfileRotate)
+
+	rotationMu.Lock()
+	rotationNext = 1
+	rotationMu.Unlock()
+
+	var got string
+	for ii := 0; ii < 4; ii++ {
+		profile, err := SelectProfile()
+		if err != nil {
+			t.Fatal(err)
+		}
+		got += profile.Name
+	}
+	if got != "bcab" {
+		t.Errorf("expected profiles in turn bcab, got %s", got)
+	}
+}
+
+func TestConfiguredProfilesRejects(t *testing.T) {
+	t.Cleanup(viper.Reset)
+
+	tests := []struct {
+		name     string
+		profiles []map[string]any
+	}{
+		{"missing name", []map[string]any{{"locale": "en-US"}}},
+		{"reserved name", []map[string]any{{"name": ProfileRotate}}},
+		{"duplicate name", []map[string]any{{"name": "a"}, {"name": "a"}}},
+		{"empty viewport", []map[string]any{{"name": "a", "viewport": map[string]any{"width": 0, "height": 600}}}},
+		{"unknown timezone", []map[string]any{{"name": "a", "timezone": "Mars/Olympus"}}},
+		{"proxy without server", []map[string]any{{"name": "a", "proxy": map[string]any{"bypass": "localhost"}}}},
+		{"proxy username without password", []map[string]any{{"name": "a", "proxy": map[string]any{"server": "http://proxy:3128", "username": "me"}}}},
+		{"socks proxy with credentials", []map[string]any{{"name": "a", "proxy": map[string]any{"server": "socks5://proxy:1080", "username": "me", "password": "secret"}}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			viper.Reset()
+			viper.Set("profiles", tt.profiles)
+			if _, err := ConfiguredProfiles(); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
@@ -0,0 +1,205 @@
+/*
+Copyright 2022
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/playwright-community/playwright-go"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/viper"
+	"golang.org/x/crypto/scrypt"
+)
+
+var (
+	ErrNoSessionKey     = errors.New("no session key; set session.key or zacks.password")
+	ErrInvalidSession   = errors.New("session file is not a saved browser session")
+	ErrSessionDecrypt   = errors.New("could not decrypt session file; was session.key changed?")
+	ErrSessionsDisabled = errors.New("session persistence is disabled")
+)
+
+// sessionMagic starts every session file and identifies its format version
+var sessionMagic = []byte("IZRSESS1")
+
+const (
+	sessionSaltSize = 16
+	sessionKeySize  = 32
+)
+
+// SessionFile is where the encrypted browser session is kept: session.file, or
+// import-zacks-rank/session.enc in the user config directory
+func SessionFile() string {
+	if fn := viper.GetString("session.file"); fn != "" {
+		return fn
+	}
+
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		dir = "."
+	}
+	return filepath.Join(dir, "import-zacks-rank", "session.enc")
+}
+
+// sessionPassphrase is session.key, falling back to the zacks password so the
+// session is never stored in the clear
+func sessionPassphrase() ([]byte, error) {
+	if viper.GetBool("session.disabled") {
+		return nil, ErrSessionsDisabled
+	}
+	if key := viper.GetString("session.key"); key != "" {
+		return []byte(key), nil
+	}
+	if password := viper.GetString("zacks.password"); password != "" {
+		return []byte(password), nil
+	}
+	return nil, ErrNoSessionKey
+}
+
+func sessionCipher(passphrase, salt []byte) (cipher.AEAD, error) {
+	key, err := scrypt.Key(passphrase, salt, 1<<15, 8, 1, sessionKeySize)
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// LoadSession decrypts the saved browser session. It returns nil and no error
+// when no session has been saved.
+func LoadSession() (*playwright.OptionalStorageState, error) {
+	passphrase, err := sessionPassphrase()
+	if err != nil {
+		return nil, err
+	}
+
+	fn := SessionFile()
+	data, err := os.ReadFile(fn)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	if !bytes.HasPrefix(data, sessionMagic) || len(data) < len(sessionMagic)+sessionSaltSize {
+		return nil, ErrInvalidSession
+	}
+	data = data[len(sessionMagic):]
+	salt, data := data[:sessionSaltSize], data[sessionSaltSize:]
+
+	aead, err := sessionCipher(passphrase, salt)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < aead.NonceSize() {
+		return nil, ErrInvalidSession
+	}
+	nonce, sealed := data[:aead.NonceSize()], data[aead.NonceSize():]
+
+	plain, err := aead.Open(nil, nonce, sealed, sessionMagic)
+	if err != nil {
+		return nil, ErrSessionDecrypt
+	}
+
+	state := &playwright.OptionalStorageState{}
+	if err := json.Unmarshal(plain, state); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSession, err)
+	}
+
+	log.Debug().Str("FileName", fn).Int("Cookies", len(state.Cookies)).Msg("loaded saved browser session")
+	return state, nil
+}
+
+// SaveSession encrypts the cookies and local storage of context to SessionFile
+func SaveSession(context playwright.BrowserContext) error {
+	passphrase, err := sessionPassphrase()
+	if err != nil {
+		return err
+	}
+
+	state, err := context.StorageState()
+	if err != nil {
+		log.Error().Err(err).Msg("could not read browser storage state")
+		return err
+	}
+
+	plain, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+
+	salt := make([]byte, sessionSaltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return err
+	}
+
+	aead, err := sessionCipher(passphrase, salt)
+	if err != nil {
+		return err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+
+	data := make([]byte, 0, len(sessionMagic)+len(salt)+len(nonce)+len(plain)+aead.Overhead())
+	data = append(data, sessionMagic...)
+	data = append(data, salt...)
+	data = append(data, nonce...)
+	data = aead.Seal(data, nonce, plain, sessionMagic)
+
+	fn := SessionFile()
+	if err := os.MkdirAll(filepath.Dir(fn), 0700); err != nil {
+		log.Error().Err(err).Str("FileName", fn).Msg("could not create session directory")
+		return err
+	}
+
+	// write to a temporary file and rename so an interruption can't leave a truncated session
+	tmp := fn + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		log.Error().Err(err).Str("FileName", tmp).Msg("could not write session file")
+		return err
+	}
+	if err := os.Rename(tmp, fn); err != nil {
+		return err
+	}
+
+	log.Debug().Str("FileName", fn).Int("Cookies", len(state.Cookies)).Msg("saved browser session")
+	return nil
+}
+
+// ClearSession deletes the saved browser session so the next run logs in again
+func ClearSession() error {
+	fn := SessionFile()
+	if err := os.Remove(fn); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,332 @@
+// Copyright 2022
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package migrations embeds the versioned DDL for the tables owned by
+// import-zacks-rank and applies it to the database.
+//
+// Migration files live in sql/ and are named NNNN_description.up.sql and
+// NNNN_description.down.sql. Applied versions are tracked in the
+// zacks_schema_migrations table. A down script that starts with a
+// "-- destructive: <reason>" line deletes data and is only run when forced.
+package migrations
+
+import (
+	"context"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/rs/zerolog/log"
+)
+
+//go:embed sql/*.sql
+var sqlFiles embed.FS
+
+var (
+	ErrSchemaBehind = errors.New("database schema is behind, run `import-zacks-rank migrate up`")
+	ErrSchemaAhead  = errors.New("database schema is newer than this binary")
+	ErrDestructive  = errors.New("reverting this migration deletes data; pass --force to confirm")
+)
+
+var (
+	migrationFilenameRegex = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+	destructiveRegex       = regexp.MustCompile(`^--\s*destructive:\s*(.*)`)
+)
+
+// Migration is a single versioned schema change
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+	// Destructive is why reverting the migration deletes data, or empty
+	Destructive string
+}
+
+// Status describes whether a migration has been applied to the database
+type Status struct {
+	*Migration
+	Applied   bool
+	AppliedAt time.Time
+}
+
+// All returns the embedded migrations sorted by version
+func All() ([]*Migration, error) {
+	entries, err := fs.ReadDir(sqlFiles, "sql")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int]*Migration)
+	for _, entry := range entries {
+		match := migrationFilenameRegex.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("invalid migration filename %q", entry.Name())
+		}
+
+		version, _ := strconv.Atoi(match[1])
+		contents, err := sqlFiles.ReadFile("sql/" + entry.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = migration
+		}
+
+		if migration.Name != match[2] {
+			return nil, fmt.Errorf("migration %d has mismatched names %q and %q", version, migration.Name, match[2])
+		}
+
+		if match[3] == "up" {
+			migration.Up = string(contents)
+		} else {
+			migration.Down = string(contents)
+			if match := destructiveRegex.FindStringSubmatch(migration.Down); match != nil {
+				migration.Destructive = strings.TrimSpace(match[1])
+			}
+		}
+	}
+
+	migrations := make([]*Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.Up == "" {
+			return nil, fmt.Errorf("migration %d is missing an up script", migration.Version)
+		}
+		migrations = append(migrations, migration)
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return migrations, nil
+}
+
+// Latest returns the highest embedded migration version
+func Latest() int {
+	migrations, err := All()
+	if err != nil || len(migrations) == 0 {
+		return 0
+	}
+	return migrations[len(migrations)-1].Version
+}
+
+func ensureVersionTable(ctx context.Context, conn *pgx.Conn) error {
+	_, err := conn.Exec(ctx, `CREATE TABLE IF NOT EXISTS zacks_schema_migrations (
+		version    INTEGER PRIMARY KEY,
+		name       TEXT NOT NULL,
+		applied_at TIMESTAMPTZ NOT NULL DEFAULT now()
+	)`)
+	return err
+}
+
+// appliedVersions reads zacks_schema_migrations; a database without the table
+// has no migrations applied. The table is only created by Up so that reading
+// the status leaves the database untouched.
+func appliedVersions(ctx context.Context, conn *pgx.Conn) (map[int]time.Time, error) {
+	var exists bool
+	if err := conn.QueryRow(ctx, "SELECT to_regclass('zacks_schema_migrations') IS NOT NULL").Scan(&exists); err != nil {
+		return nil, err
+	}
+	if !exists {
+		return map[int]time.Time{}, nil
+	}
+
+	rows, err := conn.Query(ctx, "SELECT version, applied_at FROM zacks_schema_migrations")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := make(map[int]time.Time)
+	for rows.Next() {
+		var (
+			version   int
+			appliedAt time.Time
+		)
+		if err := rows.Scan(&version, &appliedAt); err != nil {
+			return nil, err
+		}
+		applied[version] = appliedAt
+	}
+
+	return applied, rows.Err()
+}
+
+// CurrentVersion returns the highest migration version applied to the database
+func CurrentVersion(ctx context.Context, conn *pgx.Conn) (int, error) {
+	applied, err := appliedVersions(ctx, conn)
+	if err != nil {
+		return 0, err
+	}
+
+	current := 0
+	for version := range applied {
+		if version > current {
+			current = version
+		}
+	}
+	return current, nil
+}
+
+// StatusOf lists every embedded migration along with whether it has been applied
+func StatusOf(ctx context.Context, conn *pgx.Conn) ([]*Status, error) {
+	migrations, err := All()
+	if err != nil {
+		return nil, err
+	}
+
+	applied, err := appliedVersions(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]*Status, len(migrations))
+	for idx, migration := range migrations {
+		appliedAt, ok := applied[migration.Version]
+		statuses[idx] = &Status{
+			Migration: migration,
+			Applied:   ok,
+			AppliedAt: appliedAt,
+		}
+	}
+
+	return statuses, nil
+}
+
+// Up applies pending migrations in version order. If steps is greater than
+// zero at most that many migrations are applied. Each migration runs in its
+// own transaction.
+func Up(ctx context.Context, conn *pgx.Conn, steps int) (int, error) {
+	if err := ensureVersionTable(ctx, conn); err != nil {
+		return 0, err
+	}
+
+	statuses, err := StatusOf(ctx, conn)
+	if err != nil {
+		return 0, err
+	}
+
+	cnt := 0
+	for _, status := range statuses {
+		if status.Applied {
+			continue
+		}
+		if steps > 0 && cnt >= steps {
+			break
+		}
+
+		log.Info().Int("Version", status.Version).Str("Name", status.Name).Msg("applying migration")
+		if err := apply(ctx, conn, status.Up, func(tx pgx.Tx) error {
+			_, err := tx.Exec(ctx, "INSERT INTO zacks_schema_migrations (version, name) VALUES ($1, $2)", status.Version, status.Name)
+			return err
+		}); err != nil {
+			log.Error().Err(err).Int("Version", status.Version).Str("Name", status.Name).Msg("migration failed")
+			return cnt, err
+		}
+		cnt++
+	}
+
+	return cnt, nil
+}
+
+// Down reverts the most recently applied migrations. If steps is zero or less
+// a single migration is reverted. A destructive migration is only reverted
+// when force is set; otherwise Down stops before it with ErrDestructive.
+func Down(ctx context.Context, conn *pgx.Conn, steps int, force bool) (int, error) {
+	if steps <= 0 {
+		steps = 1
+	}
+
+	statuses, err := StatusOf(ctx, conn)
+	if err != nil {
+		return 0, err
+	}
+
+	cnt := 0
+	for idx := len(statuses) - 1; idx >= 0 && cnt < steps; idx-- {
+		status := statuses[idx]
+		if !status.Applied {
+			continue
+		}
+
+		if status.Down == "" {
+			return cnt, fmt.Errorf("migration %d (%s) cannot be reverted, it has no down script", status.Version, status.Name)
+		}
+
+		if status.Destructive != "" && !force {
+			return cnt, fmt.Errorf("%w: migration %d (%s) %s", ErrDestructive, status.Version, status.Name, status.Destructive)
+		}
+
+		log.Info().Int("Version", status.Version).Str("Name", status.Name).Msg("reverting migration")
+		if err := apply(ctx, conn, status.Down, func(tx pgx.Tx) error {
+			_, err := tx.Exec(ctx, "DELETE FROM zacks_schema_migrations WHERE version=$1", status.Version)
+			return err
+		}); err != nil {
+			log.Error().Err(err).Int("Version", status.Version).Str("Name", status.Name).Msg("revert failed")
+			return cnt, err
+		}
+		cnt++
+	}
+
+	return cnt, nil
+}
+
+func apply(ctx context.Context, conn *pgx.Conn, script string, record func(pgx.Tx) error) error {
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, script); err != nil {
+		return err
+	}
+
+	if err := record(tx); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// EnsureCurrent returns ErrSchemaBehind if the database has not been migrated
+// to the latest embedded version and ErrSchemaAhead if it has been migrated
+// past it
+func EnsureCurrent(ctx context.Context, conn *pgx.Conn) error {
+	current, err := CurrentVersion(ctx, conn)
+	if err != nil {
+		return err
+	}
+
+	latest := Latest()
+	switch {
+	case current < latest:
+		return fmt.Errorf("%w (database at version %d, binary expects %d)", ErrSchemaBehind, current, latest)
+	case current > latest:
+		return fmt.Errorf("%w (database at version %d, binary expects %d)", ErrSchemaAhead, current, latest)
+	}
+
+	return nil
+}
@@ -0,0 +1,2 @@
+-- destructive: drops zacks_financials and every rating loaded into it
+DROP TABLE IF EXISTS zacks_financials;
@@ -0,0 +1,141 @@
+-- zacks_financials holds one row per security per screener run
+CREATE TABLE IF NOT EXISTS zacks_financials (
+    ticker                                             TEXT,
+    composite_figi                                     TEXT NOT NULL,
+    event_date                                         DATE NOT NULL,
+    in_sp500                                           BOOLEAN,
+    month_of_fiscal_yr_end                             INTEGER,
+    optionable                                         BOOLEAN,
+    sector                                             TEXT,
+    industry                                           TEXT,
+    shares_outstanding_mil                             DOUBLE PRECISION,
+    market_cap_mil                                     DOUBLE PRECISION,
+    avg_volume                                         BIGINT,
+    wk_high_52                                         DOUBLE PRECISION,
+    wk_low_52                                          DOUBLE PRECISION,
+    price_as_percent_of_52wk_hl                        REAL,
+    beta                                               REAL,
+    percent_price_change_1wk                           REAL,
+    percent_price_change_4wk                           REAL,
+    percent_price_change_12wk                          REAL,
+    percent_price_change_ytd                           REAL,
+    relative_price_change                              REAL,
+    zacks_rank                                         INTEGER,
+    zacks_rank_change_indicator                        INTEGER,
+    zacks_industry_rank                                INTEGER,
+    value_score                                        TEXT,
+    growth_score                                       TEXT,
+    momentum_score                                     TEXT,
+    vgm_score                                          TEXT,
+    current_avg_broker_rec                             REAL,
+    num_brokers_in_rating                              INTEGER,
+    num_rating_strong_buy_or_buy                       INTEGER,
+    percent_rating_strong_buy_or_buy                   REAL,
+    num_rating_hold                                    INTEGER,
+    num_rating_strong_sell_or_sell                     INTEGER,
+    percent_rating_strong_sell_or_sell                 REAL,
+    percent_rating_change_4wk                          REAL,
+    industry_rank_of_abr                               INTEGER,
+    rank_in_industry_of_abr                            INTEGER,
+    change_in_avg_rec                                  REAL,
+    number_rating_upgrades                             INTEGER,
+    number_rating_downgrades                           INTEGER,
+    percent_rating_hold                                REAL,
+    percent_rating_upgrades                            REAL,
+    percent_rating_downgrades                          REAL,
+    average_target_price                               DOUBLE PRECISION,
+    earnings_esp                                       REAL,
+    last_eps_surprise_percent                          REAL,
+    previous_eps_surprise_percent                      REAL,
+    avg_eps_surprise_last_4_qtrs                       REAL,
+    actual_eps_used_in_surprise_dollars_per_share      REAL,
+    last_qtr_eps                                       REAL,
+    last_reported_qtr_date                             DATE,
+    last_yr_eps_f0_before_nri                          REAL,
+    twelve_mo_trailing_eps                             REAL,
+    last_reported_fiscal_yr                            DATE,
+    last_eps_report_date                               DATE,
+    next_eps_report_date                               DATE,
+    percent_change_q0_est                              REAL,
+    percent_change_q2_est                              REAL,
+    percent_change_f1_est                              REAL,
+    percent_change_q1_est                              REAL,
+    percent_change_f2_est                              REAL,
+    percent_change_lt_growth_est                       REAL,
+    q0_consensus_est_last_completed_fiscal_qtr         REAL,
+    number_of_analysts_in_q0_consensus                 INTEGER,
+    q1_consensus_est                                   REAL,
+    number_of_analysts_in_q1_consensus                 INTEGER,
+    stdev_q1_q1_consensus_ratio                        REAL,
+    q2_consensus_est_next_fiscal_qtr                   REAL,
+    number_of_analysts_in_q2_consensus                 INTEGER,
+    stdev_q2_q2_consensus_ratio                        REAL,
+    f0_consensus_est                                   REAL,
+    number_of_analysts_in_f0_consensus                 REAL,
+    f1_consensus_est                                   REAL,
+    number_of_analysts_in_f1_consensus                 INTEGER,
+    stdev_f1_f1_consensus_ratio                        REAL,
+    f2_consensus_est                                   REAL,
+    number_of_analysts_in_f2_consensus                 INTEGER,
+    five_yr_hist_eps_growth                            REAL,
+    long_term_growth_consensus_est                     REAL,
+    percent_change_eps                                 REAL,
+    last_yrs_growth                                    REAL,
+    this_yrs_est_growth                                REAL,
+    percent_ratio_of_q1_q0                             REAL,
+    percent_ratio_of_q1_prior_yr_q1_actual_q           REAL,
+    sales_growth                                       REAL,
+    five_yr_historical_sales_growth                    REAL,
+    q1_consensus_sales_est_mil                         REAL,
+    f1_consensus_sales_est_mil                         REAL,
+    pe_trailing_12_months                              REAL,
+    pe_f1                                              REAL,
+    pe_f2                                              REAL,
+    peg_ratio                                          REAL,
+    price_to_cash_flow                                 REAL,
+    price_to_sales                                     REAL,
+    price_to_book                                      REAL,
+    current_roe_ttm                                    REAL,
+    current_roi_ttm                                    REAL,
+    roi_5_yr_avg                                       REAL,
+    current_roa_ttm                                    REAL,
+    roa_5_yr_avg                                       REAL,
+    market_value_to_number_analysts                    REAL,
+    annual_sales_mil                                   REAL,
+    cost_of_goods_sold_mil                             REAL,
+    ebitda_mil                                         REAL,
+    ebit_mil                                           REAL,
+    pretax_income_mil                                  REAL,
+    net_income_mil                                     REAL,
+    cash_flow_mil                                      REAL,
+    net_income_growth_f0_f_neg1                        REAL,
+    twelve_mo_net_income_current_to_last_percent       REAL,
+    twelve_mo_net_income_current_1q_to_last_1q_percent REAL,
+    div_yield_percent                                  REAL,
+    five_yr_div_yield_percent                          REAL,
+    five_yr_hist_div_growth_percent                    REAL,
+    dividend                                           REAL,
+    net_margin_percent                                 REAL,
+    turnover                                           REAL,
+    operating_margin_12_mo_percent                     REAL,
+    inventory_turnover                                 REAL,
+    asset_utilization                                  REAL,
+    receivables_mil                                    REAL,
+    intangibles_mil                                    REAL,
+    inventory_mil                                      REAL,
+    current_assets_mil                                 REAL,
+    current_liabilities_mil                            REAL,
+    long_term_debt_mil                                 REAL,
+    preferred_equity_mil                               REAL,
+    common_equity_mil                                  REAL,
+    book_value                                         REAL,
+    debt_to_total_capital                              REAL,
+    debt_to_equity_ratio                               REAL,
+    current_ratio                                      REAL,
+    quick_ratio                                        REAL,
+    cash_ratio                                         REAL,
+    CONSTRAINT zacks_financials_pkey PRIMARY KEY (composite_figi, event_date)
+);
+
+CREATE INDEX IF NOT EXISTS zacks_financials_event_date_idx ON zacks_financials (event_date);
+CREATE INDEX IF NOT EXISTS zacks_financials_ticker_idx ON zacks_financials (ticker);
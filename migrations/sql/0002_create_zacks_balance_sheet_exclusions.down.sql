@@ -0,0 +1,2 @@
+-- destructive: drops zacks_balance_sheet_exclusions and the recorded exclusions
+DROP TABLE IF EXISTS zacks_balance_sheet_exclusions;
@@ -0,0 +1,7 @@
+-- securities the balance-sheet scraper should no longer try to look up
+CREATE TABLE IF NOT EXISTS zacks_balance_sheet_exclusions (
+    ticker         TEXT NOT NULL,
+    composite_figi TEXT
+);
+
+CREATE INDEX IF NOT EXISTS zacks_balance_sheet_exclusions_composite_figi_idx ON zacks_balance_sheet_exclusions (composite_figi);
@@ -0,0 +1,212 @@
+/*
+Copyright 2022
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kothar/go-backblaze"
+	"github.com/rs/zerolog/log"
+)
+
+// B2Store keeps objects in a Backblaze B2 bucket. The B2 API does not take a
+// context, so ctx is only checked before each call.
+type B2Store struct {
+	BucketName     string
+	KeyID          string
+	ApplicationKey string
+
+	once   sync.Once
+	bucket *backblaze.Bucket
+	err    error
+}
+
+// NewB2Store returns a store for bucketName; credentials are checked on first use
+func NewB2Store(bucketName, keyID, applicationKey string) (*B2Store, error) {
+	if bucketName == "" {
+		return nil, fmt.Errorf("backblaze.bucket is not set")
+	}
+	return &B2Store{
+		BucketName:     bucketName,
+		KeyID:          keyID,
+		ApplicationKey: applicationKey,
+	}, nil
+}
+
+func (store *B2Store) Name() string { return "b2://" + store.BucketName }
+
+func (store *B2Store) connect(ctx context.Context) (*backblaze.Bucket, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	store.once.Do(func() {
+		b2, err := backblaze.NewB2(backblaze.Credentials{
+			KeyID:          store.KeyID,
+			ApplicationKey: store.ApplicationKey,
+		})
+		if err != nil {
+			log.Error().Err(err).Str("BucketName", store.BucketName).Msg("authorize backblaze failed")
+			store.err = err
+			return
+		}
+
+		bucket, err := b2.Bucket(store.BucketName)
+		if err != nil {
+			log.Error().Err(err).Str("BucketName", store.BucketName).Msg("lookup bucket failed")
+			store.err = err
+			return
+		}
+		if bucket == nil {
+			log.Error().Str("BucketName", store.BucketName).Msg("bucket does not exist")
+			store.err = fmt.Errorf("bucket %s not found", store.BucketName)
+			return
+		}
+
+		store.bucket = bucket
+	})
+
+	return store.bucket, store.err
+}
+
+// Put sends sha1 with the upload; B2 rejects the upload if the content does not match
+func (store *B2Store) Put(ctx context.Context, key string, r io.Reader, size int64, sha1 string) (*ObjectInfo, error) {
+	bucket, err := store.connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := bucket.UploadHashedFile(key, make(map[string]string), r, sha1, size)
+	if err != nil {
+		return nil, err
+	}
+
+	return b2ObjectInfo(file), nil
+}
+
+func (store *B2Store) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	bucket, err := store.connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := store.Stat(ctx, key); err != nil {
+		return nil, err
+	}
+
+	_, reader, err := bucket.DownloadFileByName(key)
+	return reader, err
+}
+
+func (store *B2Store) List(ctx context.Context, prefix string) ([]*ObjectInfo, error) {
+	bucket, err := store.connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	objects := make([]*ObjectInfo, 0)
+	start := ""
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		resp, err := bucket.ListFileNamesWithPrefix(start, 1000, prefix, "")
+		if err != nil {
+			return nil, err
+		}
+
+		for idx := range resp.Files {
+			if resp.Files[idx].Action == backblaze.Upload {
+				objects = append(objects, b2ObjectInfo(&resp.Files[idx].File))
+			}
+		}
+
+		if resp.NextFileName == "" {
+			return objects, nil
+		}
+		start = resp.NextFileName
+	}
+}
+
+func (store *B2Store) Stat(ctx context.Context, key string) (*ObjectInfo, error) {
+	bucket, err := store.connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := bucket.ListFileNamesWithPrefix(key, 1, key, "")
+	if err != nil {
+		return nil, err
+	}
+
+	if len(resp.Files) == 0 || resp.Files[0].Name != key || resp.Files[0].Action != backblaze.Upload {
+		return nil, fmt.Errorf("%s: %w", key, ErrNotFound)
+	}
+
+	return b2ObjectInfo(&resp.Files[0].File), nil
+}
+
+// Delete removes every version of key
+func (store *B2Store) Delete(ctx context.Context, key string) error {
+	bucket, err := store.connect(ctx)
+	if err != nil {
+		return err
+	}
+
+	resp, err := bucket.ListFileVersions(key, "", 100)
+	if err != nil {
+		return err
+	}
+
+	deleted := 0
+	for _, version := range resp.Files {
+		if version.Name != key {
+			continue
+		}
+		if _, err := bucket.DeleteFileVersion(version.Name, version.ID); err != nil {
+			return err
+		}
+		deleted++
+	}
+
+	if deleted == 0 {
+		return fmt.Errorf("%s: %w", key, ErrNotFound)
+	}
+	return nil
+}
+
+func b2ObjectInfo(file *backblaze.File) *ObjectInfo {
+	// large files have no content SHA1; B2 reports "none" and clients
+	// conventionally record it in the large_file_sha1 file info instead
+	checksum := strings.TrimPrefix(file.ContentSha1, "unverified:")
+	if checksum == "none" {
+		checksum = file.FileInfo["large_file_sha1"]
+	}
+
+	return &ObjectInfo{
+		Key:      file.Name,
+		Size:     file.ContentLength,
+		SHA1:     checksum,
+		Modified: time.UnixMilli(file.UploadTimestamp),
+	}
+}
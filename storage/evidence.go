@@ -0,0 +1,101 @@
+/*
+Copyright 2022
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package storage
+
+import (
+	"archive/zip"
+	"context"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+
+	"github.com/rs/zerolog/log"
+)
+
+// EvidencePrefix is the key prefix failure evidence bundles are archived under;
+// they are not datasets and are not recorded in the manifest
+const EvidencePrefix = "evidence/"
+
+// ArchiveEvidence zips the evidence bundle in dir and uploads it to the
+// configured store as evidence/<bundle name>.zip
+func ArchiveEvidence(ctx context.Context, dir string) (*UploadResult, error) {
+	store, err := New()
+	if err != nil {
+		return nil, err
+	}
+
+	tmp, err := os.CreateTemp("", "evidence-*.zip")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := zipDir(tmp, dir); err != nil {
+		tmp.Close()
+		log.Error().Err(err).Str("Dir", dir).Msg("could not zip evidence bundle")
+		return nil, err
+	}
+	if err := tmp.Close(); err != nil {
+		return nil, err
+	}
+
+	key := path.Join(EvidencePrefix, filepath.Base(dir)+".zip")
+	return UploadFile(ctx, store, key, tmp.Name())
+}
+
+// zipDir writes the regular files directly in dir to w as a zip archive
+func zipDir(w io.Writer, dir string) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+
+	archive := zip.NewWriter(w)
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() {
+			continue
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		header, err := zip.FileInfoHeader(info)
+		if err != nil {
+			return err
+		}
+		header.Method = zip.Deflate
+
+		dst, err := archive.CreateHeader(header)
+		if err != nil {
+			return err
+		}
+
+		src, err := os.Open(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return err
+		}
+		_, err = io.Copy(dst, src)
+		src.Close()
+		if err != nil {
+			return err
+		}
+	}
+
+	return archive.Close()
+}
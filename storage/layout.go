@@ -0,0 +1,123 @@
+/*
+Copyright 2022
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package storage
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// Datasets archived by import-zacks-rank
+const (
+	DatasetRatings      = "ratings"
+	DatasetBalanceSheet = "balance-sheet"
+)
+
+// RejectsDataset is the dataset the rows of dataset that failed validation
+// are archived under, e.g. ratings-rejects
+func RejectsDataset(dataset string) string {
+	return dataset + "-rejects"
+}
+
+// Layout decides the key a dataset's parquet file for a date is stored under
+type Layout interface {
+	Name() string
+	Key(dataset string, date time.Time) string
+	// Parse is the inverse of Key; ok is false for keys the layout did not produce
+	Parse(key string) (dataset string, date time.Time, ok bool)
+}
+
+// Layouts returns every supported layout
+func Layouts() []Layout {
+	return []Layout{&LegacyLayout{}, &PartitionedLayout{}}
+}
+
+// LegacyLayout stores files as <year>/<name>-YYYYMMDD.parquet, e.g.
+// 2024/zacks-20240503.parquet for ratings
+type LegacyLayout struct{}
+
+func (layout *LegacyLayout) Name() string { return "legacy" }
+
+func (layout *LegacyLayout) Key(dataset string, date time.Time) string {
+	name := dataset
+	if dataset == DatasetRatings {
+		name = "zacks"
+	}
+	return fmt.Sprintf("%d/%s-%s.parquet", date.Year(), name, date.Format("20060102"))
+}
+
+var legacyKeyRegex = regexp.MustCompile(`^\d{4}/(.+)-(\d{8})\.parquet$`)
+
+func (layout *LegacyLayout) Parse(key string) (string, time.Time, bool) {
+	match := legacyKeyRegex.FindStringSubmatch(key)
+	if match == nil {
+		return "", time.Time{}, false
+	}
+
+	date, err := time.Parse("20060102", match[2])
+	if err != nil {
+		return "", time.Time{}, false
+	}
+
+	dataset := match[1]
+	if dataset == "zacks" {
+		dataset = DatasetRatings
+	}
+	return dataset, date, true
+}
+
+// PartitionedLayout stores files in Hive-style partitions, e.g.
+// dataset=ratings/year=2024/month=05/date=2024-05-03/part-0.parquet
+type PartitionedLayout struct{}
+
+func (layout *PartitionedLayout) Name() string { return "partitioned" }
+
+func (layout *PartitionedLayout) Key(dataset string, date time.Time) string {
+	return fmt.Sprintf("dataset=%s/year=%d/month=%02d/date=%s/part-0.parquet",
+		dataset, date.Year(), int(date.Month()), date.Format("2006-01-02"))
+}
+
+var partitionedKeyRegex = regexp.MustCompile(`^dataset=([^/]+)/year=\d{4}/month=\d{2}/date=(\d{4}-\d{2}-\d{2})/part-0\.parquet$`)
+
+func (layout *PartitionedLayout) Parse(key string) (string, time.Time, bool) {
+	match := partitionedKeyRegex.FindStringSubmatch(key)
+	if match == nil {
+		return "", time.Time{}, false
+	}
+
+	date, err := time.Parse("2006-01-02", match[2])
+	if err != nil {
+		return "", time.Time{}, false
+	}
+	return match[1], date, true
+}
+
+// ConfiguredLayout returns the layout selected by archive.layout
+func ConfiguredLayout() (Layout, error) {
+	switch layout := strings.ToLower(viper.GetString("archive.layout")); layout {
+	case "", "legacy":
+		return &LegacyLayout{}, nil
+	case "partitioned", "hive":
+		return &PartitionedLayout{}, nil
+	default:
+		return nil, fmt.Errorf("unknown archive layout %q; expected legacy or partitioned", layout)
+	}
+}
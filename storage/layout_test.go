@@ -0,0 +1,114 @@
+/*
+Copyright 2022
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+func TestLayouts(t *testing.T) {
+	date := time.Date(2024, 5, 3, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		layout  Layout
+		dataset string
+		key     string
+	}{
+		{&LegacyLayout{}, DatasetRatings, "2024/zacks-20240503.parquet"},
+		{&LegacyLayout{}, DatasetBalanceSheet, "2024/balance-sheet-20240503.parquet"},
+		{&LegacyLayout{}, RejectsDataset(DatasetRatings), "2024/ratings-rejects-20240503.parquet"},
+		{&PartitionedLayout{}, DatasetRatings, "dataset=ratings/year=2024/month=05/date=2024-05-03/part-0.parquet"},
+		{&PartitionedLayout{}, RejectsDataset(DatasetBalanceSheet), "dataset=balance-sheet-rejects/year=2024/month=05/date=2024-05-03/part-0.parquet"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.layout.Name()+"/"+tt.dataset, func(t *testing.T) {
+			if key := tt.layout.Key(tt.dataset, date); key != tt.key {
+				t.Errorf("expected key %s, got %s", tt.key, key)
+			}
+
+			dataset, parsed, ok := tt.layout.Parse(tt.key)
+			if !ok || dataset != tt.dataset || !parsed.Equal(date) {
+				t.Errorf("expected %s %s, got %s %s (ok=%v)", tt.dataset, date.Format("2006-01-02"), dataset, parsed.Format("2006-01-02"), ok)
+			}
+
+			// a key is only recognized by the layout that produced it
+			for _, other := range Layouts() {
+				if other.Name() == tt.layout.Name() {
+					continue
+				}
+				if _, _, ok := other.Parse(tt.key); ok {
+					t.Errorf("%s layout parsed %s", other.Name(), tt.key)
+				}
+			}
+		})
+	}
+}
+
+func TestLayoutParseIgnoresOtherKeys(t *testing.T) {
+	for _, key := range []string{
+		ManifestKey,
+		"2024/zacks-2024053.parquet",
+		"2024/zacks-20240503.csv",
+		"zacks-20240503.parquet",
+		"dataset=ratings/year=2024/month=05/date=2024-05-03/part-1.parquet",
+		"dataset=ratings/date=2024-05-03/part-0.parquet",
+	} {
+		for _, layout := range Layouts() {
+			if dataset, _, ok := layout.Parse(key); ok {
+				t.Errorf("%s layout parsed %s as %s", layout.Name(), key, dataset)
+			}
+		}
+	}
+}
+
+func TestConfiguredLayout(t *testing.T) {
+	t.Cleanup(viper.Reset)
+
+	tests := []struct {
+		value string
+		name  string
+	}{
+		{"", "legacy"},
+		{"legacy", "legacy"},
+		{"Partitioned", "partitioned"},
+		{"hive", "partitioned"},
+		{"flat", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			viper.Set("archive.layout", tt.value)
+			layout, err := ConfiguredLayout()
+			if tt.name == "" {
+				if err == nil {
+					t.Fatalf("expected an error for layout %q", tt.value)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if layout.Name() != tt.name {
+				t.Errorf("expected %s, got %s", tt.name, layout.Name())
+			}
+		})
+	}
+}
@@ -0,0 +1,195 @@
+/*
+Copyright 2022
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package storage
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// LocalStore keeps objects as files below a directory, e.g. a NAS mount
+type LocalStore struct {
+	Dir string
+}
+
+func NewLocalStore(dir string) (*LocalStore, error) {
+	if dir == "" {
+		return nil, fmt.Errorf("archive.local.dir is not set")
+	}
+	return &LocalStore{Dir: dir}, nil
+}
+
+func (store *LocalStore) Name() string { return "file://" + store.Dir }
+
+// path maps key to a file below Dir, rejecting keys that would escape it
+func (store *LocalStore) path(key string) (string, error) {
+	clean := filepath.Clean("/" + filepath.FromSlash(key))
+	if clean == string(filepath.Separator) || strings.Contains(key, "..") {
+		return "", fmt.Errorf("invalid key %q", key)
+	}
+	return filepath.Join(store.Dir, clean), nil
+}
+
+func (store *LocalStore) Put(ctx context.Context, key string, r io.Reader, size int64, sha1 string) (*ObjectInfo, error) {
+	fn, err := store.path(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(fn), 0755); err != nil {
+		return nil, err
+	}
+
+	// write to a temporary file and rename so readers never see a partial object
+	tmp, err := os.CreateTemp(filepath.Dir(fn), ".upload-*")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(tmp.Name())
+
+	written, err := io.Copy(tmp, r)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return nil, err
+	}
+	if size >= 0 && written != size {
+		return nil, fmt.Errorf("%s: wrote %d bytes, expected %d", key, written, size)
+	}
+
+	checksum, err := fileSHA1(tmp.Name())
+	if err != nil {
+		return nil, err
+	}
+	if sha1 != "" && checksum != sha1 {
+		return nil, fmt.Errorf("%s: %w", key, ErrChecksumMismatch)
+	}
+
+	if err := os.Rename(tmp.Name(), fn); err != nil {
+		return nil, err
+	}
+
+	return store.Stat(ctx, key)
+}
+
+func (store *LocalStore) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	fn, err := store.path(key)
+	if err != nil {
+		return nil, err
+	}
+
+	fh, err := os.Open(fn)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("%s: %w", key, ErrNotFound)
+	}
+	return fh, err
+}
+
+func (store *LocalStore) List(ctx context.Context, prefix string) ([]*ObjectInfo, error) {
+	objects := make([]*ObjectInfo, 0)
+	err := filepath.WalkDir(store.Dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) && path == store.Dir {
+				return filepath.SkipDir
+			}
+			return err
+		}
+		if d.IsDir() || strings.HasPrefix(d.Name(), ".upload-") {
+			return nil
+		}
+
+		rel, err := filepath.Rel(store.Dir, path)
+		if err != nil {
+			return err
+		}
+		key := filepath.ToSlash(rel)
+		if !strings.HasPrefix(key, prefix) {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		objects = append(objects, &ObjectInfo{Key: key, Size: info.Size(), Modified: info.ModTime()})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(objects, func(i, j int) bool { return objects[i].Key < objects[j].Key })
+	return objects, nil
+}
+
+func (store *LocalStore) Stat(ctx context.Context, key string) (*ObjectInfo, error) {
+	fn, err := store.path(key)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := os.Stat(fn)
+	if errors.Is(err, fs.ErrNotExist) || (err == nil && info.IsDir()) {
+		return nil, fmt.Errorf("%s: %w", key, ErrNotFound)
+	} else if err != nil {
+		return nil, err
+	}
+
+	checksum, err := fileSHA1(fn)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ObjectInfo{Key: key, Size: info.Size(), SHA1: checksum, Modified: info.ModTime()}, nil
+}
+
+func fileSHA1(fn string) (string, error) {
+	fh, err := os.Open(fn)
+	if err != nil {
+		return "", err
+	}
+	defer fh.Close()
+
+	hash := sha1.New()
+	if _, err := io.Copy(hash, fh); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+func (store *LocalStore) Delete(ctx context.Context, key string) error {
+	fn, err := store.path(key)
+	if err != nil {
+		return err
+	}
+
+	err = os.Remove(fn)
+	if errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("%s: %w", key, ErrNotFound)
+	}
+	return err
+}
@@ -0,0 +1,136 @@
+/*
+Copyright 2022
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func newTestStore(t *testing.T) *LocalStore {
+	t.Helper()
+	store, err := NewLocalStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return store
+}
+
+func TestLocalStore(t *testing.T) {
+	ctx := context.Background()
+	store := newTestStore(t)
+
+	const (
+		content  = "hello"
+		checksum = "aaf4c61ddcc5e8a2dabede0f3b482cd9aea9434d"
+	)
+
+	obj, err := store.Put(ctx, "2024/zacks-20240503.parquet", strings.NewReader(content), int64(len(content)), checksum)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if obj.Size != int64(len(content)) || obj.SHA1 != checksum {
+		t.Errorf("expected size %d and sha1 %s, got %d and %s", len(content), checksum, obj.Size, obj.SHA1)
+	}
+
+	reader, err := store.Get(ctx, "2024/zacks-20240503.parquet")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := io.ReadAll(reader)
+	reader.Close()
+	if err != nil || string(data) != content {
+		t.Errorf("expected %q, got %q (%v)", content, data, err)
+	}
+
+	if _, err := store.Put(ctx, "2024/zacks-20240506.parquet", strings.NewReader("world"), 5, ""); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Put(ctx, "2023/zacks-20231229.parquet", strings.NewReader("older"), 5, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	objects, err := store.List(ctx, "2024/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys := make([]string, len(objects))
+	for idx, obj := range objects {
+		keys[idx] = obj.Key
+	}
+	if got := strings.Join(keys, ","); got != "2024/zacks-20240503.parquet,2024/zacks-20240506.parquet" {
+		t.Errorf("unexpected listing %s", got)
+	}
+
+	if err := store.Delete(ctx, "2024/zacks-20240503.parquet"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Stat(ctx, "2024/zacks-20240503.parquet"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected %v after delete, got %v", ErrNotFound, err)
+	}
+	if _, err := store.Get(ctx, "2024/zacks-20240503.parquet"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected %v after delete, got %v", ErrNotFound, err)
+	}
+	if err := store.Delete(ctx, "2024/zacks-20240503.parquet"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected %v deleting a missing object, got %v", ErrNotFound, err)
+	}
+}
+
+func TestLocalStorePutRejects(t *testing.T) {
+	ctx := context.Background()
+	store := newTestStore(t)
+
+	tests := []struct {
+		name   string
+		key    string
+		size   int64
+		sha1   string
+		errMsg string
+		err    error
+	}{
+		{"key escaping the directory", "../outside.parquet", 5, "", "invalid key", nil},
+		{"empty key", "", 5, "", "invalid key", nil},
+		{"short read", "a.parquet", 6, "", "expected 6", nil},
+		{"wrong checksum", "a.parquet", 5, "0000000000000000000000000000000000000000", "", ErrChecksumMismatch},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := store.Put(ctx, tt.key, strings.NewReader("hello"), tt.size, tt.sha1)
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+			if tt.err != nil && !errors.Is(err, tt.err) {
+				t.Errorf("expected %v, got %v", tt.err, err)
+			}
+			if !strings.Contains(err.Error(), tt.errMsg) {
+				t.Errorf("expected error containing %q, got %v", tt.errMsg, err)
+			}
+		})
+	}
+
+	objects, err := store.List(ctx, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(objects) != 0 {
+		t.Errorf("failed puts left %d objects behind", len(objects))
+	}
+}
@@ -0,0 +1,232 @@
+/*
+Copyright 2022
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/penny-vault/import-zacks-rank/common"
+	"github.com/rs/zerolog/log"
+)
+
+// ManifestKey is where the dataset manifest is stored, at the top of the archive
+const ManifestKey = "manifest.json"
+
+// ManifestEntry describes one archived partition
+type ManifestEntry struct {
+	Dataset       string    `json:"dataset"`
+	Date          string    `json:"date"`
+	Key           string    `json:"key"`
+	Rows          int       `json:"rows"`
+	Size          int64     `json:"size"`
+	SHA1          string    `json:"sha1"`
+	SchemaVersion int       `json:"schema_version"`
+	ToolVersion   string    `json:"tool_version"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// Manifest lists every partition in the archive
+type Manifest struct {
+	Layout     string           `json:"layout"`
+	UpdatedAt  time.Time        `json:"updated_at"`
+	Partitions []*ManifestEntry `json:"partitions"`
+}
+
+// LoadManifest reads the manifest from store; a missing manifest is empty
+func LoadManifest(ctx context.Context, store ArchiveStore) (*Manifest, error) {
+	manifest := &Manifest{Partitions: make([]*ManifestEntry, 0)}
+
+	reader, err := store.Get(ctx, ManifestKey)
+	if errors.Is(err, ErrNotFound) {
+		return manifest, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(data, manifest); err != nil {
+		return nil, fmt.Errorf("could not parse %s: %w", ManifestKey, err)
+	}
+
+	return manifest, nil
+}
+
+// Find returns the entry for key or nil
+func (manifest *Manifest) Find(key string) *ManifestEntry {
+	for _, entry := range manifest.Partitions {
+		if entry.Key == key {
+			return entry
+		}
+	}
+	return nil
+}
+
+// Upsert adds entry or replaces the entry with the same key
+func (manifest *Manifest) Upsert(entry *ManifestEntry) {
+	for idx, existing := range manifest.Partitions {
+		if existing.Key == entry.Key {
+			manifest.Partitions[idx] = entry
+			return
+		}
+	}
+
+	manifest.Partitions = append(manifest.Partitions, entry)
+	sort.Slice(manifest.Partitions, func(i, j int) bool {
+		a, b := manifest.Partitions[i], manifest.Partitions[j]
+		if a.Dataset != b.Dataset {
+			return a.Dataset < b.Dataset
+		}
+		return a.Date < b.Date
+	})
+}
+
+// Save writes the manifest to store
+func (manifest *Manifest) Save(ctx context.Context, store ArchiveStore) error {
+	manifest.UpdatedAt = time.Now().UTC()
+
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	checksum := sha1.Sum(data)
+	_, err = store.Put(ctx, ManifestKey, bytes.NewReader(data), int64(len(data)), hex.EncodeToString(checksum[:]))
+	return err
+}
+
+// Archiver uploads dataset files to Store at the keys given by Layout and
+// keeps the manifest up to date
+type Archiver struct {
+	Store  ArchiveStore
+	Layout Layout
+}
+
+// NewArchiver returns an archiver for the configured store and layout
+func NewArchiver() (*Archiver, error) {
+	store, err := New()
+	if err != nil {
+		return nil, err
+	}
+
+	layout, err := ConfiguredLayout()
+	if err != nil {
+		return nil, err
+	}
+
+	return &Archiver{Store: store, Layout: layout}, nil
+}
+
+// Archive uploads fn, which holds rows records of dataset for date, and records it in the manifest
+func (archiver *Archiver) Archive(ctx context.Context, dataset string, date time.Time, fn string, rows, schemaVersion int) (*UploadResult, error) {
+	key := archiver.Layout.Key(dataset, date)
+
+	result, err := UploadFile(ctx, archiver.Store, key, fn)
+	if err != nil {
+		return nil, err
+	}
+
+	manifest, err := LoadManifest(ctx, archiver.Store)
+	if err != nil {
+		log.Error().Err(err).Str("Store", archiver.Store.Name()).Msg("could not load archive manifest")
+		return result, err
+	}
+
+	entry := &ManifestEntry{
+		Dataset:       dataset,
+		Date:          date.Format("2006-01-02"),
+		Key:           key,
+		Rows:          rows,
+		Size:          result.Object.Size,
+		SHA1:          result.Object.SHA1,
+		SchemaVersion: schemaVersion,
+		ToolVersion:   common.CurrentVersion.String(),
+		UpdatedAt:     time.Now().UTC(),
+	}
+
+	// a skipped upload only rewrites the manifest if its entry is missing or stale
+	if existing := manifest.Find(key); result.Action == UploadSkipped && existing != nil &&
+		existing.SHA1 == entry.SHA1 && existing.Rows == entry.Rows && existing.SchemaVersion == entry.SchemaVersion {
+		return result, nil
+	}
+
+	manifest.Layout = archiver.Layout.Name()
+	manifest.Upsert(entry)
+	if err := manifest.Save(ctx, archiver.Store); err != nil {
+		log.Error().Err(err).Str("Store", archiver.Store.Name()).Msg("could not save archive manifest")
+		return result, err
+	}
+
+	log.Info().Str("Dataset", dataset).Str("Date", entry.Date).Int("Rows", rows).Str("Key", key).Msg("archive manifest updated")
+	return result, nil
+}
+
+// Partitions returns the keys of every archived file of dataset by date
+// (YYYY-MM-DD), recognizing both the legacy and the partitioned layout. When
+// a date is stored in both, the key of the configured layout wins.
+func (archiver *Archiver) Partitions(ctx context.Context, dataset string) (map[string]string, error) {
+	objects, err := archiver.Store.List(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+
+	partitions := make(map[string]string)
+	for _, obj := range objects {
+		for _, layout := range Layouts() {
+			objDataset, date, ok := layout.Parse(obj.Key)
+			if !ok || objDataset != dataset {
+				continue
+			}
+
+			dateStr := date.Format("2006-01-02")
+			if _, exists := partitions[dateStr]; !exists || layout.Name() == archiver.Layout.Name() {
+				partitions[dateStr] = obj.Key
+			}
+		}
+	}
+
+	return partitions, nil
+}
+
+// Locate returns the key dataset is stored under for date, checking the
+// configured layout first and then the others
+func (archiver *Archiver) Locate(ctx context.Context, dataset string, date time.Time) (string, error) {
+	layouts := append([]Layout{archiver.Layout}, Layouts()...)
+	for _, layout := range layouts {
+		key := layout.Key(dataset, date)
+		if _, err := archiver.Store.Stat(ctx, key); err == nil {
+			return key, nil
+		} else if !errors.Is(err, ErrNotFound) {
+			return "", err
+		}
+	}
+
+	return "", fmt.Errorf("%s %s: %w", dataset, date.Format("2006-01-02"), ErrNotFound)
+}
@@ -0,0 +1,135 @@
+/*
+Copyright 2022
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package storage
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestArchiverManifest(t *testing.T) {
+	ctx := context.Background()
+	archiver := &Archiver{Store: newTestStore(t), Layout: &PartitionedLayout{}}
+	fn := filepath.Join(t.TempDir(), "zacks-20240503.parquet")
+	if err := os.WriteFile(fn, []byte("ratings"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	archive := func(dataset string, date time.Time, rows int) {
+		t.Helper()
+		if _, err := archiver.Archive(ctx, dataset, date, fn, rows, 2); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	may3 := time.Date(2024, 5, 3, 0, 0, 0, 0, time.UTC)
+	may2 := time.Date(2024, 5, 2, 0, 0, 0, 0, time.UTC)
+	archive(DatasetRatings, may3, 3)
+	archive(DatasetRatings, may2, 3)
+	archive(DatasetBalanceSheet, may3, 1)
+	// archiving an identical file with a new row count updates the entry in place
+	archive(DatasetRatings, may3, 2)
+
+	manifest, err := LoadManifest(ctx, archiver.Store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if manifest.Layout != "partitioned" {
+		t.Errorf("expected partitioned layout, got %s", manifest.Layout)
+	}
+
+	var got []string
+	for _, entry := range manifest.Partitions {
+		got = append(got, entry.Dataset+"@"+entry.Date)
+	}
+	if want := "balance-sheet@2024-05-03,ratings@2024-05-02,ratings@2024-05-03"; strings.Join(got, ",") != want {
+		t.Errorf("expected partitions %s, got %s", want, strings.Join(got, ","))
+	}
+
+	entry := manifest.Find(archiver.Layout.Key(DatasetRatings, may3))
+	if entry == nil {
+		t.Fatal("ratings 2024-05-03 is not in the manifest")
+	}
+	if entry.Rows != 2 || entry.SchemaVersion != 2 || entry.Size != int64(len("ratings")) || entry.SHA1 == "" {
+		t.Errorf("unexpected manifest entry %+v", entry)
+	}
+}
+
+func TestArchiverLocate(t *testing.T) {
+	ctx := context.Background()
+	store := newTestStore(t)
+	legacy := &Archiver{Store: store, Layout: &LegacyLayout{}}
+	partitioned := &Archiver{Store: store, Layout: &PartitionedLayout{}}
+
+	put := func(key string) {
+		t.Helper()
+		if _, err := store.Put(ctx, key, strings.NewReader("x"), 1, ""); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	may2 := time.Date(2024, 5, 2, 0, 0, 0, 0, time.UTC)
+	may3 := time.Date(2024, 5, 3, 0, 0, 0, 0, time.UTC)
+	may6 := time.Date(2024, 5, 6, 0, 0, 0, 0, time.UTC)
+	put(legacy.Layout.Key(DatasetRatings, may2))
+	put(legacy.Layout.Key(DatasetRatings, may3))
+	put(partitioned.Layout.Key(DatasetRatings, may3))
+
+	tests := []struct {
+		name     string
+		archiver *Archiver
+		date     time.Time
+		key      string
+	}{
+		{"configured layout", legacy, may2, "2024/zacks-20240502.parquet"},
+		{"falls back to the other layout", partitioned, may2, "2024/zacks-20240502.parquet"},
+		{"configured layout wins", partitioned, may3, "dataset=ratings/year=2024/month=05/date=2024-05-03/part-0.parquet"},
+		{"configured layout wins (legacy)", legacy, may3, "2024/zacks-20240503.parquet"},
+		{"missing", partitioned, may6, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, err := tt.archiver.Locate(ctx, DatasetRatings, tt.date)
+			if tt.key == "" {
+				if !errors.Is(err, ErrNotFound) {
+					t.Fatalf("expected %v, got %v", ErrNotFound, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if key != tt.key {
+				t.Errorf("expected %s, got %s", tt.key, key)
+			}
+
+			partitions, err := tt.archiver.Partitions(ctx, DatasetRatings)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if partitions[tt.date.Format("2006-01-02")] != tt.key {
+				t.Errorf("expected Partitions to agree with Locate, got %s", partitions[tt.date.Format("2006-01-02")])
+			}
+		})
+	}
+}
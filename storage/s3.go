@@ -0,0 +1,144 @@
+/*
+Copyright 2022
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package storage
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+// S3Config configures an S3-compatible store such as AWS S3 or MinIO
+type S3Config struct {
+	// Endpoint is host[:port] without scheme, e.g. s3.amazonaws.com or localhost:9000
+	Endpoint  string
+	Region    string
+	Bucket    string
+	AccessKey string
+	SecretKey string
+	UseSSL    bool
+}
+
+// sha1MetadataKey is the user metadata (x-amz-meta-sha1) the content SHA1 is
+// recorded in so UploadFile can skip identical files. S3 does not check it
+// against the content; Put verifies uploads with MD5 instead.
+const sha1MetadataKey = "Sha1"
+
+// S3Store keeps objects in an S3-compatible bucket
+type S3Store struct {
+	bucket string
+	client *minio.Client
+}
+
+func NewS3Store(config *S3Config) (*S3Store, error) {
+	if config.Endpoint == "" || config.Bucket == "" {
+		return nil, fmt.Errorf("archive.s3.endpoint and archive.s3.bucket must be set")
+	}
+
+	client, err := minio.New(config.Endpoint, &minio.Options{
+		Creds:  credentials.NewStaticV4(config.AccessKey, config.SecretKey, ""),
+		Secure: config.UseSSL,
+		Region: config.Region,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &S3Store{bucket: config.Bucket, client: client}, nil
+}
+
+func (store *S3Store) Name() string { return "s3://" + store.bucket }
+
+// Put sends Content-MD5 with each part, which S3 checks on receipt, and
+// records sha1 in the object metadata. For single-part uploads the ETag the
+// server returns is the MD5 of the stored object and is compared with the MD5
+// of the bytes read from r; multipart ETags are not content hashes, so those
+// uploads rely on the per-part check alone.
+func (store *S3Store) Put(ctx context.Context, key string, r io.Reader, size int64, sha1 string) (*ObjectInfo, error) {
+	hash := md5.New()
+	info, err := store.client.PutObject(ctx, store.bucket, key, io.TeeReader(r, hash), size, minio.PutObjectOptions{
+		ContentType:    "application/octet-stream",
+		UserMetadata:   map[string]string{sha1MetadataKey: sha1},
+		SendContentMd5: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	etag := strings.ToLower(strings.Trim(info.ETag, `"`))
+	if checksum := hex.EncodeToString(hash.Sum(nil)); !strings.Contains(etag, "-") && etag != checksum {
+		return nil, fmt.Errorf("%s: etag %s, expected md5 %s: %w", key, etag, checksum, ErrChecksumMismatch)
+	}
+
+	return store.Stat(ctx, key)
+}
+
+func (store *S3Store) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	if _, err := store.Stat(ctx, key); err != nil {
+		return nil, err
+	}
+	return store.client.GetObject(ctx, store.bucket, key, minio.GetObjectOptions{})
+}
+
+func (store *S3Store) List(ctx context.Context, prefix string) ([]*ObjectInfo, error) {
+	objects := make([]*ObjectInfo, 0)
+	for obj := range store.client.ListObjects(ctx, store.bucket, minio.ListObjectsOptions{
+		Prefix:    prefix,
+		Recursive: true,
+	}) {
+		if obj.Err != nil {
+			return nil, obj.Err
+		}
+		objects = append(objects, &ObjectInfo{Key: obj.Key, Size: obj.Size, Modified: obj.LastModified})
+	}
+	return objects, nil
+}
+
+func (store *S3Store) Stat(ctx context.Context, key string) (*ObjectInfo, error) {
+	obj, err := store.client.StatObject(ctx, store.bucket, key, minio.StatObjectOptions{})
+	if err != nil {
+		return nil, s3Error(key, err)
+	}
+	return &ObjectInfo{
+		Key:      obj.Key,
+		Size:     obj.Size,
+		SHA1:     obj.UserMetadata[sha1MetadataKey],
+		Modified: obj.LastModified,
+	}, nil
+}
+
+func (store *S3Store) Delete(ctx context.Context, key string) error {
+	if _, err := store.Stat(ctx, key); err != nil {
+		return err
+	}
+	return s3Error(key, store.client.RemoveObject(ctx, store.bucket, key, minio.RemoveObjectOptions{}))
+}
+
+func s3Error(key string, err error) error {
+	var resp minio.ErrorResponse
+	if errors.As(err, &resp) && resp.Code == "NoSuchKey" {
+		return fmt.Errorf("%s: %w", key, ErrNotFound)
+	}
+	return err
+}
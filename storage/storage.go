@@ -0,0 +1,164 @@
+/*
+Copyright 2022
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package storage archives import output to object storage
+package storage
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/viper"
+)
+
+var (
+	ErrNotFound         = errors.New("object not found")
+	ErrUnknownBackend   = errors.New("unknown archive backend; expected b2, s3 or local")
+	ErrChecksumMismatch = errors.New("uploaded object does not match local file")
+)
+
+// UploadAction reports what UploadFile did
+type UploadAction string
+
+const (
+	// UploadSkipped means an identical object was already stored
+	UploadSkipped UploadAction = "skipped"
+	// Uploaded means there was no object at the key
+	Uploaded UploadAction = "uploaded"
+	// UploadReplaced means a different object at the key was overwritten
+	UploadReplaced UploadAction = "replaced"
+)
+
+// UploadResult describes the outcome of UploadFile
+type UploadResult struct {
+	Action UploadAction
+	Object *ObjectInfo
+}
+
+// ObjectInfo describes an object in an ArchiveStore
+type ObjectInfo struct {
+	Key  string
+	Size int64
+	// SHA1 is the hex encoded SHA1 of the content, or empty when the store
+	// does not know it (e.g. objects uploaded by other tools)
+	SHA1     string
+	Modified time.Time
+}
+
+// ArchiveStore is a flat key/value object store. Keys use '/' as separator
+// regardless of the backend, e.g. 2024/zacks-20240503.parquet.
+type ArchiveStore interface {
+	// Name identifies the store in logs, e.g. b2://zacks-investment
+	Name() string
+	// Put stores size bytes read from r under key, replacing any existing
+	// object. sha1 is the hex SHA1 of the content; the store records it and
+	// fails if the bytes it received do not match.
+	Put(ctx context.Context, key string, r io.Reader, size int64, sha1 string) (*ObjectInfo, error)
+	// Get opens the object at key; the caller must close it
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	// List returns the objects whose key starts with prefix, sorted by key
+	List(ctx context.Context, prefix string) ([]*ObjectInfo, error)
+	// Stat returns ErrNotFound when there is no object at key
+	Stat(ctx context.Context, key string) (*ObjectInfo, error)
+	Delete(ctx context.Context, key string) error
+}
+
+// New returns the store selected by archive.backend
+func New() (ArchiveStore, error) {
+	switch backend := viper.GetString("archive.backend"); backend {
+	case "", "b2":
+		return NewB2Store(
+			viper.GetString("backblaze.bucket"),
+			viper.GetString("backblaze.application_id"),
+			viper.GetString("backblaze.application_key"),
+		)
+	case "s3":
+		return NewS3Store(&S3Config{
+			Endpoint:  viper.GetString("archive.s3.endpoint"),
+			Region:    viper.GetString("archive.s3.region"),
+			Bucket:    viper.GetString("archive.s3.bucket"),
+			AccessKey: viper.GetString("archive.s3.access_key"),
+			SecretKey: viper.GetString("archive.s3.secret_key"),
+			UseSSL:    viper.GetBool("archive.s3.use_ssl"),
+		})
+	case "local":
+		return NewLocalStore(viper.GetString("archive.local.dir"))
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnknownBackend, backend)
+	}
+}
+
+// UploadFile uploads the local file fn to key unless an object with the same
+// SHA1 and size is already stored there. The stored object is checked
+// against the local file after upload.
+func UploadFile(ctx context.Context, store ArchiveStore, key, fn string) (*UploadResult, error) {
+	fh, err := os.Open(fn)
+	if err != nil {
+		log.Error().Err(err).Str("FileName", fn).Msg("could not open file for upload")
+		return nil, err
+	}
+	defer fh.Close()
+
+	hash := sha1.New()
+	size, err := io.Copy(hash, fh)
+	if err != nil {
+		log.Error().Err(err).Str("FileName", fn).Msg("could not read file for upload")
+		return nil, err
+	}
+	checksum := hex.EncodeToString(hash.Sum(nil))
+
+	action := Uploaded
+	existing, err := store.Stat(ctx, key)
+	switch {
+	case errors.Is(err, ErrNotFound):
+	case err != nil:
+		log.Error().Err(err).Str("Key", key).Str("Store", store.Name()).Msg("could not check for existing object")
+		return nil, err
+	case existing.SHA1 == checksum && existing.Size == size:
+		log.Info().Str("Key", key).Str("SHA1", checksum).Str("Store", store.Name()).Str("Action", string(UploadSkipped)).Msg("identical object already archived")
+		return &UploadResult{Action: UploadSkipped, Object: existing}, nil
+	default:
+		action = UploadReplaced
+	}
+
+	if _, err := fh.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	obj, err := store.Put(ctx, key, fh, size, checksum)
+	if err != nil {
+		log.Error().Err(err).Str("Key", key).Str("Store", store.Name()).Msg("upload failed")
+		return nil, err
+	}
+
+	if obj.Size != size || obj.SHA1 != checksum {
+		log.Error().Str("Key", key).Str("Store", store.Name()).
+			Int64("ExpectedSize", size).Int64("Size", obj.Size).
+			Str("ExpectedSHA1", checksum).Str("SHA1", obj.SHA1).
+			Msg("uploaded object does not match local file")
+		return nil, fmt.Errorf("%s: %w", key, ErrChecksumMismatch)
+	}
+
+	log.Info().Str("Key", obj.Key).Int64("Size", obj.Size).Str("SHA1", obj.SHA1).Str("Store", store.Name()).Str("Action", string(action)).Msg("archived file")
+	return &UploadResult{Action: action, Object: obj}, nil
+}
@@ -0,0 +1,64 @@
+/*
+Copyright 2022
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package storage
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestUploadFile(t *testing.T) {
+	ctx := context.Background()
+	store := newTestStore(t)
+	dir := t.TempDir()
+
+	write := func(content string) string {
+		fn := filepath.Join(dir, "zacks-20240503.parquet")
+		if err := os.WriteFile(fn, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return fn
+	}
+
+	tests := []struct {
+		name    string
+		content string
+		action  UploadAction
+	}{
+		{"new object", "first", Uploaded},
+		{"identical object", "first", UploadSkipped},
+		{"different object", "second", UploadReplaced},
+		{"same size, different content", "third!", UploadReplaced},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := UploadFile(ctx, store, "2024/zacks-20240503.parquet", write(tt.content))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result.Action != tt.action {
+				t.Errorf("expected %s, got %s", tt.action, result.Action)
+			}
+			if result.Object.Size != int64(len(tt.content)) {
+				t.Errorf("expected size %d, got %d", len(tt.content), result.Object.Size)
+			}
+		})
+	}
+}
@@ -0,0 +1,337 @@
+/*
+Copyright 2022
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package zacks
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// ExpandInputs turns a list of files, directories and glob patterns into a
+// sorted, de-duplicated list of CSV files. Directories are searched recursively.
+func ExpandInputs(inputs []string) ([]string, error) {
+	seen := make(map[string]bool)
+	files := make([]string, 0, len(inputs))
+
+	add := func(fn string) {
+		if !seen[fn] {
+			seen[fn] = true
+			files = append(files, fn)
+		}
+	}
+
+	for _, input := range inputs {
+		matches, err := filepath.Glob(input)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %w", input, err)
+		}
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("%q: %w", input, fs.ErrNotExist)
+		}
+
+		for _, match := range matches {
+			info, err := os.Stat(match)
+			if err != nil {
+				return nil, err
+			}
+
+			if !info.IsDir() {
+				add(match)
+				continue
+			}
+
+			err = filepath.WalkDir(match, func(path string, d fs.DirEntry, err error) error {
+				if err != nil {
+					return err
+				}
+				if !d.IsDir() && strings.EqualFold(filepath.Ext(path), ".csv") {
+					add(path)
+				}
+				return nil
+			})
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	sort.Strings(files)
+	return files, nil
+}
+
+// Checkpoint records which event dates a backfill has finished so an
+// interrupted run can resume where it left off
+type Checkpoint struct {
+	path string
+	mu   sync.Mutex
+
+	Completed map[string]time.Time `json:"completed"`
+}
+
+// LoadCheckpoint reads the checkpoint at path; a missing file yields an empty checkpoint
+func LoadCheckpoint(path string) (*Checkpoint, error) {
+	checkpoint := &Checkpoint{
+		path:      path,
+		Completed: make(map[string]time.Time),
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return checkpoint, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(data, checkpoint); err != nil {
+		return nil, fmt.Errorf("could not parse checkpoint %s: %w", path, err)
+	}
+
+	if checkpoint.Completed == nil {
+		checkpoint.Completed = make(map[string]time.Time)
+	}
+
+	return checkpoint, nil
+}
+
+// Done reports whether dateStr has already been loaded
+func (checkpoint *Checkpoint) Done(dateStr string) bool {
+	checkpoint.mu.Lock()
+	defer checkpoint.mu.Unlock()
+
+	_, ok := checkpoint.Completed[dateStr]
+	return ok
+}
+
+// MarkDone records dateStr as loaded and writes the checkpoint to disk
+func (checkpoint *Checkpoint) MarkDone(dateStr string) error {
+	checkpoint.mu.Lock()
+	defer checkpoint.mu.Unlock()
+
+	checkpoint.Completed[dateStr] = time.Now()
+
+	data, err := json.MarshalIndent(checkpoint, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	// write to a temporary file and rename so an interruption can't leave a truncated checkpoint
+	tmp := checkpoint.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, checkpoint.path)
+}
+
+// BackfillStats summarizes a backfill run
+type BackfillStats struct {
+	Files     int
+	Loaded    int
+	Skipped   int
+	Failed    int
+	Duplicate int
+	// Undated lists the files whose event date could not be determined; they
+	// are also counted in Failed
+	Undated []string
+}
+
+// Backfill loads many archived screens. Files are parsed in parallel but
+// delivered to the pipeline sinks one date at a time, in date order.
+type Backfill struct {
+	// Pipeline provides the stages and sinks; its Source is not used
+	Pipeline *Pipeline
+	// Workers is the number of files parsed concurrently
+	Workers int
+	// Checkpoint, if set, is consulted to skip and updated to record completed dates
+	Checkpoint *Checkpoint
+	// Existing lists dates already present in the database; they are skipped
+	Existing map[string]bool
+	// Dates dates each file before it is parsed; BackfillDateResolver("") when nil
+	Dates *DateResolver
+}
+
+type backfillJob struct {
+	batch *Batch
+	done  chan error
+}
+
+// Run backfills the given files
+func (backfill *Backfill) Run(ctx context.Context, files []string) (*BackfillStats, error) {
+	stats := &BackfillStats{Files: len(files)}
+
+	resolver := backfill.Dates
+	if resolver == nil {
+		resolver = BackfillDateResolver("")
+	}
+
+	// resolve dates up front so batches can be ordered and skipped before parsing
+	byDate := make(map[string]*Batch, len(files))
+	for _, fn := range files {
+		batch := &Batch{Filename: filepath.Base(fn), Path: fn}
+		date, _, err := resolver.Resolve(batch)
+		if err != nil {
+			log.Error().Err(err).Str("FileName", fn).Msg("skipping file; could not determine event date")
+			stats.Failed++
+			stats.Undated = append(stats.Undated, fn)
+			continue
+		}
+		batch.DateStr = date.Format("2006-01-02")
+
+		if prev, ok := byDate[batch.DateStr]; ok {
+			log.Warn().Str("EventDate", batch.DateStr).Str("FileName", fn).Str("Previous", prev.Path).Msg("multiple files for the same date; using the last one")
+			stats.Duplicate++
+		}
+		byDate[batch.DateStr] = batch
+	}
+
+	todo := make([]*Batch, 0, len(byDate))
+	for dateStr, batch := range byDate {
+		if backfill.Existing[dateStr] {
+			log.Debug().Str("EventDate", dateStr).Msg("date already in database; skipping")
+			stats.Skipped++
+			continue
+		}
+		if backfill.Checkpoint != nil && backfill.Checkpoint.Done(dateStr) {
+			log.Debug().Str("EventDate", dateStr).Msg("date already in checkpoint; skipping")
+			stats.Skipped++
+			continue
+		}
+		todo = append(todo, batch)
+	}
+
+	sort.Slice(todo, func(i, j int) bool {
+		return todo[i].DateStr < todo[j].DateStr
+	})
+
+	log.Info().Int("Files", len(files)).Int("Dates", len(todo)).Int("Skipped", stats.Skipped).Msg("starting backfill")
+
+	workers := backfill.Workers
+	if workers < 1 {
+		workers = 1
+	}
+
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	jobs := make(chan *backfillJob)
+	// ordered holds jobs in date order; its buffer bounds how many parsed
+	// batches can be waiting in memory
+	ordered := make(chan *backfillJob, workers)
+
+	go func() {
+		defer close(jobs)
+		defer close(ordered)
+
+		for _, batch := range todo {
+			job := &backfillJob{batch: batch, done: make(chan error, 1)}
+			select {
+			case ordered <- job:
+			case <-runCtx.Done():
+				return
+			}
+			select {
+			case jobs <- job:
+			case <-runCtx.Done():
+				job.done <- runCtx.Err()
+				return
+			}
+		}
+	}()
+
+	var wg sync.WaitGroup
+	for ii := 0; ii < workers; ii++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range jobs {
+				job.done <- backfill.parse(runCtx, job.batch)
+			}
+		}()
+	}
+
+	for job := range ordered {
+		err := <-job.done
+		if err == nil {
+			err = backfill.Pipeline.Deliver(runCtx, job.batch)
+		}
+
+		// release the parsed records as soon as the date is delivered
+		job.batch.Data = nil
+		job.batch.Records = nil
+
+		if err != nil {
+			if ctx.Err() != nil {
+				break
+			}
+			log.Error().Err(err).Str("EventDate", job.batch.DateStr).Str("FileName", job.batch.Path).Msg("backfill of date failed")
+			stats.Failed++
+			continue
+		}
+
+		if backfill.Checkpoint != nil {
+			if err := backfill.Checkpoint.MarkDone(job.batch.DateStr); err != nil {
+				log.Error().Err(err).Msg("could not write checkpoint")
+				cancel()
+				wg.Wait()
+				return stats, err
+			}
+		}
+
+		stats.Loaded++
+		log.Info().Str("EventDate", job.batch.DateStr).Int("Loaded", stats.Loaded).Int("Remaining", len(todo)-stats.Loaded-stats.Failed).Msg("date loaded")
+	}
+
+	cancel()
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		log.Warn().Err(err).Int("Loaded", stats.Loaded).Msg("backfill interrupted; re-run to resume from the checkpoint")
+		return stats, err
+	}
+
+	if len(stats.Undated) > 0 {
+		log.Error().Strs("Files", stats.Undated).Msg("files without a date in their name or archive path were not loaded; rename them or load them one at a time with --date")
+	}
+
+	log.Info().Int("Files", stats.Files).Int("Loaded", stats.Loaded).Int("Skipped", stats.Skipped).Int("Failed", stats.Failed).Int("Duplicate", stats.Duplicate).Int("Undated", len(stats.Undated)).Msg("backfill finished")
+	return stats, nil
+}
+
+func (backfill *Backfill) parse(ctx context.Context, batch *Batch) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	data, err := os.ReadFile(batch.Path)
+	if err != nil {
+		log.Error().Err(err).Str("FileName", batch.Path).Msg("could not read input file")
+		return err
+	}
+	batch.Data = data
+
+	return backfill.Pipeline.Process(ctx, batch)
+}
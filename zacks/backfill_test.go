@@ -0,0 +1,112 @@
+/*
+Copyright 2022
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package zacks
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestExpandInputs(t *testing.T) {
+	dir := t.TempDir()
+	for _, fn := range []string{"a.csv", "b.CSV", "notes.txt", "2024/05/c.csv"} {
+		path := filepath.Join(dir, fn)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	join := func(names ...string) []string {
+		paths := make([]string, len(names))
+		for idx, name := range names {
+			paths[idx] = filepath.Join(dir, name)
+		}
+		return paths
+	}
+
+	tests := []struct {
+		name   string
+		inputs []string
+		want   []string
+		err    error
+	}{
+		{"single file", join("notes.txt"), join("notes.txt"), nil},
+		{"directory is searched recursively", join("."), join("2024/05/c.csv", "a.csv", "b.CSV"), nil},
+		{"glob", join("*.csv"), join("a.csv"), nil},
+		{"duplicates are removed", join("a.csv", "*.csv", "."), join("2024/05/c.csv", "a.csv", "b.CSV"), nil},
+		{"missing input", join("missing.csv"), nil, fs.ErrNotExist},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			files, err := ExpandInputs(tt.inputs)
+			if tt.err != nil {
+				if !errors.Is(err, tt.err) {
+					t.Fatalf("expected %v, got %v", tt.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(files, tt.want) {
+				t.Errorf("expected %v, got %v", tt.want, files)
+			}
+		})
+	}
+}
+
+func TestCheckpoint(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "backfill.json")
+
+	checkpoint, err := LoadCheckpoint(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if checkpoint.Done("2024-05-03") {
+		t.Fatal("a new checkpoint should be empty")
+	}
+
+	if err := checkpoint.MarkDone("2024-05-03"); err != nil {
+		t.Fatal(err)
+	}
+
+	reloaded, err := LoadCheckpoint(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reloaded.Done("2024-05-03") {
+		t.Error("expected 2024-05-03 to be done after reloading")
+	}
+	if reloaded.Done("2024-05-02") {
+		t.Error("2024-05-02 was never marked done")
+	}
+
+	if err := os.WriteFile(path, []byte("{"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadCheckpoint(path); err == nil {
+		t.Error("expected an error for a corrupt checkpoint")
+	}
+}
@@ -17,22 +17,28 @@ limitations under the License.
 package zacks
 
 import (
-	"fmt"
+	"context"
 	"math"
 	"reflect"
 	"strconv"
 	"strings"
 	"time"
 
-	"github.com/penny-vault/import-zacks-rank/common"
 	"github.com/playwright-community/playwright-go"
 	"github.com/rs/zerolog/log"
 	"github.com/schollz/progressbar/v3"
-	"github.com/spf13/viper"
 )
 
-func BalanceSheet(tickers []string) (BalanceSheetList, error) {
-	page, context, browser, pw := common.StartPlaywright(viper.GetBool("playwright.headless"))
+// BalanceSheet downloads the balance sheets of tickers. If logging in fails
+// part way through, or ctx is done, the balance sheets downloaded so far are
+// returned with the error. The browser is always shut down before it returns.
+func BalanceSheet(ctx context.Context, tickers []string) (BalanceSheetList, error) {
+	page, evidence, stop, err := startLoggedIn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	// the browser is restarted periodically so tear down whichever one is current
+	defer func() { stop() }()
 
 	result := make([]*BalanceSheetRecord, 0, len(tickers)*5)
 
@@ -54,11 +60,15 @@ func BalanceSheet(tickers []string) (BalanceSheetList, error) {
 
 	completed := 0
 	for _, ticker := range tickers {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+
 		bar.Describe(ticker)
 
 		zacksTicker := strings.ReplaceAll(ticker, "/", ".")
 
-		if _, err := page.Goto(fmt.Sprintf("https://www.zacks.com/stock/quote/%s/balance-sheet?icid=quote-stock_overview-quote_nav_tracking-zcom-left_subnav_quote_navbar-balance_sheet", zacksTicker), playwright.PageGotoOptions{
+		if _, err := page.Goto(BalanceSheetURL(zacksTicker), playwright.PageGotoOptions{
 			WaitUntil: playwright.WaitUntilStateNetworkidle,
 			Timeout:   playwright.Float(20000),
 		}); err != nil {
@@ -68,7 +78,11 @@ func BalanceSheet(tickers []string) (BalanceSheetList, error) {
 		page.SetDefaultTimeout(1000)
 
 		// slow things down a bit so we don't over-whelm zacks.com
-		time.Sleep(5 * time.Second)
+		select {
+		case <-time.After(5 * time.Second):
+		case <-ctx.Done():
+			return result, ctx.Err()
+		}
 
 		// Annual Income Statement
 
@@ -76,6 +90,11 @@ func BalanceSheet(tickers []string) (BalanceSheetList, error) {
 		annual := make(map[string]*BalanceSheetRecord, 5)
 		colMap := make(map[int]string, 5)
 		if err := parseHeader("#annual_income_statement", ticker, "As-Reported-Annual", page, annual, colMap); err != nil {
+			// the browser was closed because ctx is done, not because the ticker has no balance sheet
+			if ctxErr := ctx.Err(); ctxErr != nil {
+				return result, ctxErr
+			}
+			captureEvidence(ctx, evidence, "balance-sheet-"+ticker, err)
 			// add to database
 			AddExclusion(ticker)
 			continue
@@ -116,6 +135,11 @@ func BalanceSheet(tickers []string) (BalanceSheetList, error) {
 			parseRow("#quarterly_income_statement", "Total Current Liabilities", "TotalCurrentLiabilities", page, quarterly, colMap)
 		}
 
+		// don't keep the quarterly rows of, or exclude, a ticker whose page was cut off by ctx
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+
 		// add all ARQ dimension to return val
 		for _, v := range quarterly {
 			result = append(result, v)
@@ -131,13 +155,16 @@ func BalanceSheet(tickers []string) (BalanceSheetList, error) {
 
 		// every 50 tickers restart playwright
 		if completed > 50 {
-			common.StopPlaywright(page, context, browser, pw)
-			page, context, browser, pw = common.StartPlaywright(viper.GetBool("playwright.headless"))
+			stop()
+			// the new context restores the saved session, so this only logs in
+			// again if the session has expired
+			if page, evidence, stop, err = startLoggedIn(ctx); err != nil {
+				return result, err
+			}
 			completed = 0
 		}
 	}
 
-	common.StopPlaywright(page, context, browser, pw)
 	return result, nil
 }
 
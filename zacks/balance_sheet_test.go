@@ -0,0 +1,58 @@
+/*
+Copyright 2022
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package zacks
+
+import (
+	"context"
+	"testing"
+)
+
+func TestBalanceSheet(t *testing.T) {
+	startFakeZacks(t)
+
+	records, err := BalanceSheet(context.Background(), []string{"AAPL"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	byPeriod := make(map[string]*BalanceSheetRecord, len(records))
+	for _, r := range records {
+		byPeriod[r.Dimension+" "+r.CalendarDate] = r
+	}
+
+	tests := []struct {
+		period      string
+		assets      float64
+		liabilities float64
+	}{
+		{"As-Reported-Annual 9/30/2023", 143566e6, 145308e6},
+		{"As-Reported-Annual 9/30/2022", 135405e6, 153982e6},
+		{"As-Reported-Quarterly 3/31/2024", 128416e6, 123822e6},
+		{"As-Reported-Quarterly 12/31/2023", 143692e6, 133973e6},
+	}
+
+	for _, tt := range tests {
+		r, ok := byPeriod[tt.period]
+		if !ok {
+			t.Errorf("no record for %s", tt.period)
+			continue
+		}
+		if r.Ticker != "AAPL" || r.TotalCurrentAssets != tt.assets || r.TotalCurrentLiabilities != tt.liabilities {
+			t.Errorf("%s = %s %v %v, want AAPL %v %v", tt.period, r.Ticker, r.TotalCurrentAssets, r.TotalCurrentLiabilities, tt.assets, tt.liabilities)
+		}
+	}
+}
@@ -0,0 +1,208 @@
+/*
+Copyright 2022
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package zacks
+
+import (
+	"bytes"
+	"encoding/csv"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+)
+
+// minRenameSimilarity is the lowest similarity at which a missing and an added
+// column are reported as a rename rather than as two separate changes
+const minRenameSimilarity = 0.6
+
+// ColumnRename pairs an expected column with the header that most likely replaced it
+type ColumnRename struct {
+	Expected   string
+	Found      string
+	Similarity float64
+}
+
+// ColumnDrift describes how a screener CSV header differs from the csv tags on ZacksRecord
+type ColumnDrift struct {
+	// Added are headers in the CSV that no ZacksRecord field reads
+	Added []string
+	// Missing are ZacksRecord columns that are not in the CSV and will be left empty
+	Missing []string
+	Renamed []*ColumnRename
+}
+
+// Empty reports whether the header matched exactly
+func (drift *ColumnDrift) Empty() bool {
+	return len(drift.Added) == 0 && len(drift.Missing) == 0 && len(drift.Renamed) == 0
+}
+
+// Log writes one line per drifted column
+func (drift *ColumnDrift) Log() {
+	for _, rename := range drift.Renamed {
+		log.Warn().Str("Expected", rename.Expected).Str("Found", rename.Found).Float64("Similarity", rename.Similarity).Msg("screener column renamed")
+	}
+	for _, col := range drift.Missing {
+		log.Warn().Str("Column", col).Msg("screener column missing; field will be empty")
+	}
+	for _, col := range drift.Added {
+		log.Warn().Str("Column", col).Msg("unknown screener column; it will be ignored")
+	}
+}
+
+func normalizeColumn(name string) string {
+	return strings.TrimSpace(name)
+}
+
+// ExpectedColumns returns the column names ZacksRecord reads, in field order
+func ExpectedColumns() []string {
+	recordType := reflect.TypeOf(ZacksRecord{})
+	cols := make([]string, 0, recordType.NumField())
+	for ii := 0; ii < recordType.NumField(); ii++ {
+		tag := recordType.Field(ii).Tag.Get("csv")
+		name := normalizeColumn(strings.Split(tag, ",")[0])
+		if name == "" || name == "-" {
+			continue
+		}
+		cols = append(cols, name)
+	}
+	return cols
+}
+
+// ReadHeader returns the first row of a CSV document
+func ReadHeader(data []byte) ([]string, error) {
+	data = bytes.TrimPrefix(data, []byte("\ufeff"))
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.FieldsPerRecord = -1
+	return reader.Read()
+}
+
+// DetectColumnDrift compares header against the csv tags on ZacksRecord
+func DetectColumnDrift(header []string) *ColumnDrift {
+	expected := make(map[string]bool)
+	for _, col := range ExpectedColumns() {
+		expected[col] = true
+	}
+
+	normalized := make([]string, len(header))
+	found := make(map[string]bool, len(header))
+	for idx, col := range header {
+		normalized[idx] = normalizeColumn(col)
+		found[normalized[idx]] = true
+	}
+
+	var missing, added []string
+	for _, col := range ExpectedColumns() {
+		if !found[col] {
+			missing = append(missing, col)
+		}
+	}
+	for _, col := range normalized {
+		if !expected[col] {
+			added = append(added, col)
+		}
+	}
+
+	drift := &ColumnDrift{}
+
+	// greedily pair the most similar missing and added columns
+	type candidate struct {
+		missing, added string
+		similarity     float64
+	}
+	candidates := make([]candidate, 0, len(missing)*len(added))
+	for _, m := range missing {
+		for _, a := range added {
+			if sim := columnSimilarity(m, a); sim >= minRenameSimilarity {
+				candidates = append(candidates, candidate{m, a, sim})
+			}
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].similarity > candidates[j].similarity
+	})
+
+	paired := make(map[string]bool)
+	for _, c := range candidates {
+		if paired[c.missing] || paired[c.added] {
+			continue
+		}
+		paired[c.missing] = true
+		paired[c.added] = true
+		drift.Renamed = append(drift.Renamed, &ColumnRename{
+			Expected:   c.missing,
+			Found:      c.added,
+			Similarity: c.similarity,
+		})
+	}
+
+	for _, col := range missing {
+		if !paired[col] {
+			drift.Missing = append(drift.Missing, col)
+		}
+	}
+	for _, col := range added {
+		if !paired[col] {
+			drift.Added = append(drift.Added, col)
+		}
+	}
+
+	return drift
+}
+
+// columnSimilarity returns 1 - normalized levenshtein distance of the two
+// names after case folding and collapsing whitespace
+func columnSimilarity(a, b string) float64 {
+	a = strings.Join(strings.Fields(strings.ToLower(a)), " ")
+	b = strings.Join(strings.Fields(strings.ToLower(b)), " ")
+	if a == b {
+		return 1
+	}
+
+	ra, rb := []rune(a), []rune(b)
+	longest := len(ra)
+	if len(rb) > longest {
+		longest = len(rb)
+	}
+	if longest == 0 {
+		return 1
+	}
+
+	return 1 - float64(levenshtein(ra, rb))/float64(longest)
+}
+
+func levenshtein(a, b []rune) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for jj := range prev {
+		prev[jj] = jj
+	}
+
+	for ii := 1; ii <= len(a); ii++ {
+		curr[0] = ii
+		for jj := 1; jj <= len(b); jj++ {
+			cost := 1
+			if a[ii-1] == b[jj-1] {
+				cost = 0
+			}
+			curr[jj] = min(prev[jj]+1, curr[jj-1]+1, prev[jj-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+
+	return prev[len(b)]
+}
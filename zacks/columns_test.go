@@ -0,0 +1,77 @@
+/*
+Copyright 2022
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package zacks
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDetectColumnDrift(t *testing.T) {
+	replace := func(old, new string) []string {
+		header := ExpectedColumns()
+		for idx, col := range header {
+			if col == old {
+				header[idx] = new
+			}
+		}
+		return header
+	}
+
+	tests := []struct {
+		name    string
+		header  []string
+		added   []string
+		missing []string
+		renamed []*ColumnRename
+	}{
+		{"exact header", ExpectedColumns(), nil, nil, nil},
+		{"surrounding whitespace is ignored", replace("Ticker", " Ticker "), nil, nil, nil},
+		{"new column", append(ExpectedColumns(), "ESG Score"), []string{"ESG Score"}, nil, nil},
+		{"dropped column", replace("Beta", ""), []string{""}, []string{"Beta"}, nil},
+		{"renamed column", replace("Zacks Rank", "Zacks Rank #"), nil, nil, []*ColumnRename{{Expected: "Zacks Rank", Found: "Zacks Rank #", Similarity: 1 - 2.0/12}}},
+		{"dissimilar names are not paired", replace("Beta", "Beta (60 mo)"), []string{"Beta (60 mo)"}, []string{"Beta"}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			drift := DetectColumnDrift(tt.header)
+			if !reflect.DeepEqual(drift.Added, tt.added) {
+				t.Errorf("expected added %q, got %q", tt.added, drift.Added)
+			}
+			if !reflect.DeepEqual(drift.Missing, tt.missing) {
+				t.Errorf("expected missing %q, got %q", tt.missing, drift.Missing)
+			}
+			if !reflect.DeepEqual(drift.Renamed, tt.renamed) {
+				t.Errorf("expected renamed %+v, got %+v", tt.renamed, drift.Renamed)
+			}
+			if drift.Empty() != (tt.added == nil && tt.missing == nil && tt.renamed == nil) {
+				t.Errorf("Empty() is %v", drift.Empty())
+			}
+		})
+	}
+}
+
+func TestSampleScreenHasNoDrift(t *testing.T) {
+	header, err := ReadHeader(sampleScreen(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if drift := DetectColumnDrift(header); !drift.Empty() {
+		t.Errorf("expected the fakezacks screen to match ZacksRecord, got %+v", drift)
+	}
+}
@@ -18,6 +18,9 @@ package zacks
 
 import (
 	"context"
+	"fmt"
+	"strings"
+	"time"
 
 	"github.com/jackc/pgx/v4"
 	"github.com/rs/zerolog/log"
@@ -52,500 +55,840 @@ func AddExclusion(ticker string) {
 	}
 }
 
-func SaveToDB(records []*ZacksRecord) error {
-	conn, err := pgx.Connect(context.Background(), viper.GetString("database.url"))
+// RatingsTable is the table the main screen is loaded into
+const RatingsTable = "zacks_financials"
+
+// stagingTable is the temporary table SaveToTable copies records into before merging
+const stagingTable = "zacks_staging"
+
+// zacksFinancialsColumns lists the zacks_financials columns written by SaveToDB, in the same order as ZacksRecord.dbValues
+var zacksFinancialsColumns = []string{
+	"ticker",
+	"composite_figi",
+	"event_date",
+	"in_sp500",
+	"month_of_fiscal_yr_end",
+	"optionable",
+	"sector",
+	"industry",
+	"shares_outstanding_mil",
+	"market_cap_mil",
+	"avg_volume",
+	"wk_high_52",
+	"wk_low_52",
+	"price_as_percent_of_52wk_hl",
+	"beta",
+	"percent_price_change_1wk",
+	"percent_price_change_4wk",
+	"percent_price_change_12wk",
+	"percent_price_change_ytd",
+	"relative_price_change",
+	"zacks_rank",
+	"zacks_rank_change_indicator",
+	"zacks_industry_rank",
+	"value_score",
+	"growth_score",
+	"momentum_score",
+	"vgm_score",
+	"current_avg_broker_rec",
+	"num_brokers_in_rating",
+	"num_rating_strong_buy_or_buy",
+	"percent_rating_strong_buy_or_buy",
+	"num_rating_hold",
+	"num_rating_strong_sell_or_sell",
+	"percent_rating_strong_sell_or_sell",
+	"percent_rating_change_4wk",
+	"industry_rank_of_abr",
+	"rank_in_industry_of_abr",
+	"change_in_avg_rec",
+	"number_rating_upgrades",
+	"number_rating_downgrades",
+	"percent_rating_hold",
+	"percent_rating_upgrades",
+	"percent_rating_downgrades",
+	"average_target_price",
+	"earnings_esp",
+	"last_eps_surprise_percent",
+	"previous_eps_surprise_percent",
+	"avg_eps_surprise_last_4_qtrs",
+	"actual_eps_used_in_surprise_dollars_per_share",
+	"last_qtr_eps",
+	"last_reported_qtr_date",
+	"last_yr_eps_f0_before_nri",
+	"twelve_mo_trailing_eps",
+	"last_reported_fiscal_yr",
+	"last_eps_report_date",
+	"next_eps_report_date",
+	"percent_change_q0_est",
+	"percent_change_q2_est",
+	"percent_change_f1_est",
+	"percent_change_q1_est",
+	"percent_change_f2_est",
+	"percent_change_lt_growth_est",
+	"q0_consensus_est_last_completed_fiscal_qtr",
+	"number_of_analysts_in_q0_consensus",
+	"q1_consensus_est",
+	"number_of_analysts_in_q1_consensus",
+	"stdev_q1_q1_consensus_ratio",
+	"q2_consensus_est_next_fiscal_qtr",
+	"number_of_analysts_in_q2_consensus",
+	"stdev_q2_q2_consensus_ratio",
+	"f0_consensus_est",
+	"number_of_analysts_in_f0_consensus",
+	"f1_consensus_est",
+	"number_of_analysts_in_f1_consensus",
+	"stdev_f1_f1_consensus_ratio",
+	"f2_consensus_est",
+	"number_of_analysts_in_f2_consensus",
+	"five_yr_hist_eps_growth",
+	"long_term_growth_consensus_est",
+	"percent_change_eps",
+	"last_yrs_growth",
+	"this_yrs_est_growth",
+	"percent_ratio_of_q1_q0",
+	"percent_ratio_of_q1_prior_yr_q1_actual_q",
+	"sales_growth",
+	"five_yr_historical_sales_growth",
+	"q1_consensus_sales_est_mil",
+	"f1_consensus_sales_est_mil",
+	"pe_trailing_12_months",
+	"pe_f1",
+	"pe_f2",
+	"peg_ratio",
+	"price_to_cash_flow",
+	"price_to_sales",
+	"price_to_book",
+	"current_roe_ttm",
+	"current_roi_ttm",
+	"roi_5_yr_avg",
+	"current_roa_ttm",
+	"roa_5_yr_avg",
+	"market_value_to_number_analysts",
+	"annual_sales_mil",
+	"cost_of_goods_sold_mil",
+	"ebitda_mil",
+	"ebit_mil",
+	"pretax_income_mil",
+	"net_income_mil",
+	"cash_flow_mil",
+	"net_income_growth_f0_f_neg1",
+	"twelve_mo_net_income_current_to_last_percent",
+	"twelve_mo_net_income_current_1q_to_last_1q_percent",
+	"div_yield_percent",
+	"five_yr_div_yield_percent",
+	"five_yr_hist_div_growth_percent",
+	"dividend",
+	"net_margin_percent",
+	"turnover",
+	"operating_margin_12_mo_percent",
+	"inventory_turnover",
+	"asset_utilization",
+	"receivables_mil",
+	"intangibles_mil",
+	"inventory_mil",
+	"current_assets_mil",
+	"current_liabilities_mil",
+	"long_term_debt_mil",
+	"preferred_equity_mil",
+	"common_equity_mil",
+	"book_value",
+	"debt_to_total_capital",
+	"debt_to_equity_ratio",
+	"current_ratio",
+	"quick_ratio",
+	"cash_ratio",
+}
+
+// ErrorPolicy controls what a database load does when an individual row fails
+type ErrorPolicy string
+
+const (
+	// ErrorPolicyAbort rolls back the whole import on the first failing row
+	ErrorPolicyAbort ErrorPolicy = "abort"
+	// ErrorPolicySkip skips failing rows, records them in LoadStats and commits the rest
+	ErrorPolicySkip ErrorPolicy = "skip"
+)
+
+// ConfiguredErrorPolicy returns the policy set by database.on_error, defaulting to abort
+func ConfiguredErrorPolicy() ErrorPolicy {
+	switch policy := ErrorPolicy(strings.ToLower(viper.GetString("database.on_error"))); policy {
+	case ErrorPolicySkip:
+		return policy
+	case ErrorPolicyAbort, "":
+		return ErrorPolicyAbort
+	default:
+		log.Warn().Str("OnError", string(policy)).Msg("unknown database error policy; using abort")
+		return ErrorPolicyAbort
+	}
+}
+
+// FailedRow is a row that was skipped under ErrorPolicySkip
+type FailedRow struct {
+	Ticker        string
+	CompositeFigi string
+	Err           error
+}
+
+// LoadStats reports the outcome of a database load
+type LoadStats struct {
+	Inserted int64
+	Updated  int64
+	// Skipped counts records that were not loaded because they have no composite figi
+	Skipped int64
+	// Duplicates counts records replaced by a later record for the same
+	// composite figi and event date
+	Duplicates int64
+	// Failed lists rows skipped because of an error when the policy is ErrorPolicySkip
+	Failed []*FailedRow
+}
+
+func (stats *LoadStats) fail(ticker, compositeFigi string, err error) {
+	log.Warn().Err(err).Str("Ticker", ticker).Str("CompositeFigi", compositeFigi).Msg("skipping row that failed to load")
+	stats.Failed = append(stats.Failed, &FailedRow{
+		Ticker:        ticker,
+		CompositeFigi: compositeFigi,
+		Err:           err,
+	})
+}
+
+// dbValues returns the record's values in zacksFinancialsColumns order
+func (r *ZacksRecord) dbValues() []interface{} {
+	return []interface{}{
+		r.Ticker,
+		r.CompositeFigi,
+		r.EventDate,
+		r.InSp500,
+		r.MonthOfFiscalYrEnd,
+		r.Optionable,
+		r.Sector,
+		r.Industry,
+		r.SharesOutstandingMil,
+		r.MarketCapMil,
+		r.AvgVolume,
+		r.WkHigh52,
+		r.WkLow52,
+		r.PriceAsPercentOf52wkHighLow,
+		r.Beta,
+		r.PercentPriceChange1Wk,
+		r.PercentPriceChange4Wk,
+		r.PercentPriceChange12Wk,
+		r.PercentPriceChangeYtd,
+		r.RelativePriceChange,
+		r.ZacksRank,
+		r.ZacksRankChangeIndicator,
+		r.ZacksIndustryRank,
+		r.ValueScore,
+		r.GrowthScore,
+		r.MomentumScore,
+		r.VgmScore,
+		r.CurrentAvgBrokerRec,
+		r.NumBrokersInRating,
+		r.NumRatingStrongBuyOrBuy,
+		r.PercentRatingStrongBuyOrBuy,
+		r.NumRatingHold,
+		r.NumRatingStrongSellOrSell,
+		r.PercentRatingStrongSellOrSell,
+		r.PercentRatingChange4Wk,
+		r.IndustryRankOfAbr,
+		r.RankInIndustryOfAbr,
+		r.ChangeInAvgRec,
+		r.NumberRatingUpgrades,
+		r.NumberRatingDowngrades,
+		r.PercentRatingHold,
+		r.PercentRatingUpgrades,
+		r.PercentRatingDowngrades,
+		r.AverageTargetPrice,
+		r.EarningsEsp,
+		r.LastEpsSurprisePercent,
+		r.PreviousEpsSurprisePercent,
+		r.AvgEpsSurpriseLast4Qtrs,
+		r.ActualEpsUsedInSurpriseDollarsPerShare,
+		r.LastQtrEps,
+		nullDate(r.LastReportedQtrDate),
+		r.LastYrEpsF0BeforeNri,
+		r.TwelveMoTrailingEps,
+		nullDate(r.LastReportedFiscalYr),
+		nullDate(r.LastEpsReportDate),
+		nullDate(r.NextEpsReportDate),
+		r.PercentChangeQ0Est,
+		r.PercentChangeQ2Est,
+		r.PercentChangeF1Est,
+		r.PercentChangeQ1Est,
+		r.PercentChangeF2Est,
+		r.PercentChangeLtGrowthEst,
+		r.Q0ConsensusEstLastCompletedFiscalQtr,
+		r.NumberOfAnalystsInQ0Consensus,
+		r.Q1ConsensusEst,
+		r.NumberOfAnalystsInQ1Consensus,
+		r.StdevQ1Q1ConsensusRatio,
+		r.Q2ConsensusEstNextFiscalQtr,
+		r.NumberOfAnalystsInQ2Consensus,
+		r.StdevQ2Q2ConsensusRatio,
+		r.F0ConsensusEst,
+		r.NumberOfAnalystsInF0Consensus,
+		r.F1ConsensusEst,
+		r.NumberOfAnalystsInF1Consensus,
+		r.StdevF1F1ConsensusRatio,
+		r.F2ConsensusEst,
+		r.NumberOfAnalystsInF2Consensus,
+		r.FiveYrHistEpsGrowth,
+		r.LongTermGrowthConsensusEst,
+		r.PercentChangeEps,
+		r.LastYrsGrowth,
+		r.ThisYrsEstGrowth,
+		r.PercentRatioOfQ1Q0,
+		r.PercentRatioOfQ1PriorYrQ1ActualQ,
+		r.SalesGrowth,
+		r.FiveYrHistoricalSalesGrowth,
+		r.Q1ConsensusSalesEstMil,
+		r.F1ConsensusSalesEstMil,
+		r.PeTrailing12Months,
+		r.PeF1,
+		r.PeF2,
+		r.PegRatio,
+		r.PriceToCashFlow,
+		r.PriceToSales,
+		r.PriceToBook,
+		r.CurrentRoeTtm,
+		r.CurrentRoiTtm,
+		r.Roi5YrAvg,
+		r.CurrentRoaTtm,
+		r.Roa5YrAvg,
+		r.MarketValueToNumberAnalysts,
+		r.AnnualSalesMil,
+		r.CostOfGoodsSoldMil,
+		r.EbitdaMil,
+		r.EbitMil,
+		r.PretaxIncomeMil,
+		r.NetIncomeMil,
+		r.CashFlowMil,
+		r.NetIncomeGrowthF0FNeg1,
+		r.TwelveMoNetIncomeCurrentToLastPercent,
+		r.TwelveMoNetIncomeCurrent1qToLast1qPercent,
+		r.DivYieldPercent,
+		r.FiveYrDivYieldPercent,
+		r.FiveYrHistDivGrowthPercent,
+		r.Dividend,
+		r.NetMarginPercent,
+		r.Turnover,
+		r.OperatingMargin12MoPercent,
+		r.InventoryTurnover,
+		r.AssetUtilization,
+		r.ReceivablesMil,
+		r.IntangiblesMil,
+		r.InventoryMil,
+		r.CurrentAssetsMil,
+		r.CurrentLiabilitiesMil,
+		r.LongTermDebtMil,
+		r.PreferredEquityMil,
+		r.CommonEquityMil,
+		r.BookValue,
+		r.DebtToTotalCapital,
+		r.DebtToEquityRatio,
+		r.CurrentRatio,
+		r.QuickRatio,
+		r.CashRatio,
+	}
+}
+
+// dbScanTargets returns scan destinations in zacksFinancialsColumns order. Columns
+// that may be NULL but are not nullable on ZacksRecord are scanned into
+// temporaries; call the returned function after Scan to copy them over.
+func (r *ZacksRecord) dbScanTargets() ([]interface{}, func()) {
+	var (
+		nTicker               *string
+		nInSp500              *bool
+		nOptionable           *bool
+		nSector               *string
+		nIndustry             *string
+		nValueScore           *string
+		nGrowthScore          *string
+		nMomentumScore        *string
+		nVgmScore             *string
+		nLastReportedQtrDate  *time.Time
+		nLastReportedFiscalYr *time.Time
+		nLastEpsReportDate    *time.Time
+		nNextEpsReportDate    *time.Time
+	)
+
+	targets := []interface{}{
+		&nTicker,
+		&r.CompositeFigi,
+		&r.EventDate,
+		&nInSp500,
+		&r.MonthOfFiscalYrEnd,
+		&nOptionable,
+		&nSector,
+		&nIndustry,
+		&r.SharesOutstandingMil,
+		&r.MarketCapMil,
+		&r.AvgVolume,
+		&r.WkHigh52,
+		&r.WkLow52,
+		&r.PriceAsPercentOf52wkHighLow,
+		&r.Beta,
+		&r.PercentPriceChange1Wk,
+		&r.PercentPriceChange4Wk,
+		&r.PercentPriceChange12Wk,
+		&r.PercentPriceChangeYtd,
+		&r.RelativePriceChange,
+		&r.ZacksRank,
+		&r.ZacksRankChangeIndicator,
+		&r.ZacksIndustryRank,
+		&nValueScore,
+		&nGrowthScore,
+		&nMomentumScore,
+		&nVgmScore,
+		&r.CurrentAvgBrokerRec,
+		&r.NumBrokersInRating,
+		&r.NumRatingStrongBuyOrBuy,
+		&r.PercentRatingStrongBuyOrBuy,
+		&r.NumRatingHold,
+		&r.NumRatingStrongSellOrSell,
+		&r.PercentRatingStrongSellOrSell,
+		&r.PercentRatingChange4Wk,
+		&r.IndustryRankOfAbr,
+		&r.RankInIndustryOfAbr,
+		&r.ChangeInAvgRec,
+		&r.NumberRatingUpgrades,
+		&r.NumberRatingDowngrades,
+		&r.PercentRatingHold,
+		&r.PercentRatingUpgrades,
+		&r.PercentRatingDowngrades,
+		&r.AverageTargetPrice,
+		&r.EarningsEsp,
+		&r.LastEpsSurprisePercent,
+		&r.PreviousEpsSurprisePercent,
+		&r.AvgEpsSurpriseLast4Qtrs,
+		&r.ActualEpsUsedInSurpriseDollarsPerShare,
+		&r.LastQtrEps,
+		&nLastReportedQtrDate,
+		&r.LastYrEpsF0BeforeNri,
+		&r.TwelveMoTrailingEps,
+		&nLastReportedFiscalYr,
+		&nLastEpsReportDate,
+		&nNextEpsReportDate,
+		&r.PercentChangeQ0Est,
+		&r.PercentChangeQ2Est,
+		&r.PercentChangeF1Est,
+		&r.PercentChangeQ1Est,
+		&r.PercentChangeF2Est,
+		&r.PercentChangeLtGrowthEst,
+		&r.Q0ConsensusEstLastCompletedFiscalQtr,
+		&r.NumberOfAnalystsInQ0Consensus,
+		&r.Q1ConsensusEst,
+		&r.NumberOfAnalystsInQ1Consensus,
+		&r.StdevQ1Q1ConsensusRatio,
+		&r.Q2ConsensusEstNextFiscalQtr,
+		&r.NumberOfAnalystsInQ2Consensus,
+		&r.StdevQ2Q2ConsensusRatio,
+		&r.F0ConsensusEst,
+		&r.NumberOfAnalystsInF0Consensus,
+		&r.F1ConsensusEst,
+		&r.NumberOfAnalystsInF1Consensus,
+		&r.StdevF1F1ConsensusRatio,
+		&r.F2ConsensusEst,
+		&r.NumberOfAnalystsInF2Consensus,
+		&r.FiveYrHistEpsGrowth,
+		&r.LongTermGrowthConsensusEst,
+		&r.PercentChangeEps,
+		&r.LastYrsGrowth,
+		&r.ThisYrsEstGrowth,
+		&r.PercentRatioOfQ1Q0,
+		&r.PercentRatioOfQ1PriorYrQ1ActualQ,
+		&r.SalesGrowth,
+		&r.FiveYrHistoricalSalesGrowth,
+		&r.Q1ConsensusSalesEstMil,
+		&r.F1ConsensusSalesEstMil,
+		&r.PeTrailing12Months,
+		&r.PeF1,
+		&r.PeF2,
+		&r.PegRatio,
+		&r.PriceToCashFlow,
+		&r.PriceToSales,
+		&r.PriceToBook,
+		&r.CurrentRoeTtm,
+		&r.CurrentRoiTtm,
+		&r.Roi5YrAvg,
+		&r.CurrentRoaTtm,
+		&r.Roa5YrAvg,
+		&r.MarketValueToNumberAnalysts,
+		&r.AnnualSalesMil,
+		&r.CostOfGoodsSoldMil,
+		&r.EbitdaMil,
+		&r.EbitMil,
+		&r.PretaxIncomeMil,
+		&r.NetIncomeMil,
+		&r.CashFlowMil,
+		&r.NetIncomeGrowthF0FNeg1,
+		&r.TwelveMoNetIncomeCurrentToLastPercent,
+		&r.TwelveMoNetIncomeCurrent1qToLast1qPercent,
+		&r.DivYieldPercent,
+		&r.FiveYrDivYieldPercent,
+		&r.FiveYrHistDivGrowthPercent,
+		&r.Dividend,
+		&r.NetMarginPercent,
+		&r.Turnover,
+		&r.OperatingMargin12MoPercent,
+		&r.InventoryTurnover,
+		&r.AssetUtilization,
+		&r.ReceivablesMil,
+		&r.IntangiblesMil,
+		&r.InventoryMil,
+		&r.CurrentAssetsMil,
+		&r.CurrentLiabilitiesMil,
+		&r.LongTermDebtMil,
+		&r.PreferredEquityMil,
+		&r.CommonEquityMil,
+		&r.BookValue,
+		&r.DebtToTotalCapital,
+		&r.DebtToEquityRatio,
+		&r.CurrentRatio,
+		&r.QuickRatio,
+		&r.CashRatio,
+	}
+
+	return targets, func() {
+		r.Ticker = derefString(nTicker)
+		r.InSp500 = nInSp500 != nil && *nInSp500
+		r.Optionable = nOptionable != nil && *nOptionable
+		r.Sector = derefString(nSector)
+		r.Industry = derefString(nIndustry)
+		r.ValueScore = derefString(nValueScore)
+		r.GrowthScore = derefString(nGrowthScore)
+		r.MomentumScore = derefString(nMomentumScore)
+		r.VgmScore = derefString(nVgmScore)
+		if nLastReportedQtrDate != nil {
+			r.LastReportedQtrDate = *nLastReportedQtrDate
+		}
+		if nLastReportedFiscalYr != nil {
+			r.LastReportedFiscalYr = *nLastReportedFiscalYr
+		}
+		if nLastEpsReportDate != nil {
+			r.LastEpsReportDate = *nLastEpsReportDate
+		}
+		if nNextEpsReportDate != nil {
+			r.NextEpsReportDate = *nNextEpsReportDate
+		}
+		r.syncDateStrings()
+	}
+}
+
+func derefString(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+// LoadFromDB reads all records for eventDate (YYYY-MM-DD) from zacks_financials
+func LoadFromDB(ctx context.Context, eventDate string) ([]*ZacksRecord, error) {
+	date, err := time.Parse("2006-01-02", eventDate)
+	if err != nil {
+		return nil, fmt.Errorf("invalid event date %q: %w", eventDate, err)
+	}
+
+	conn, err := pgx.Connect(ctx, viper.GetString("database.url"))
 	if err != nil {
 		log.Error().Err(err).Msg("Could not connect to database")
+		return nil, err
+	}
+	defer conn.Close(ctx)
+
+	quoted := make([]string, len(zacksFinancialsColumns))
+	for idx, col := range zacksFinancialsColumns {
+		quoted[idx] = fmt.Sprintf(`"%s"`, col)
+	}
+
+	rows, err := conn.Query(ctx, fmt.Sprintf("SELECT %s FROM zacks_financials WHERE event_date=$1 ORDER BY ticker", strings.Join(quoted, ", ")), date)
+	if err != nil {
+		log.Error().Err(err).Str("EventDate", eventDate).Msg("could not query zacks_financials")
+		return nil, err
+	}
+	defer rows.Close()
+
+	records := make([]*ZacksRecord, 0, 5000)
+	for rows.Next() {
+		r := &ZacksRecord{}
+		targets, finish := r.dbScanTargets()
+		if err := rows.Scan(targets...); err != nil {
+			log.Error().Err(err).Str("EventDate", eventDate).Msg("could not scan zacks_financials row")
+			return nil, err
+		}
+		finish()
+		records = append(records, r)
+	}
+
+	return records, rows.Err()
+}
+
+// nullDate maps the zero time, used for dates missing from the screen, to NULL
+func nullDate(t time.Time) interface{} {
+	if t.IsZero() {
+		return nil
+	}
+	return t
+}
+
+// SaveToDB bulk loads records into zacks_financials
+func SaveToDB(ctx context.Context, records []*ZacksRecord) (*LoadStats, error) {
+	return SaveToTable(ctx, records, RatingsTable)
+}
+
+// SaveToTable bulk loads records into table, which must have the columns of
+// zacks_financials and a unique key on (composite_figi, event_date). Records
+// are copied into a temporary staging table and merged with a single
+// INSERT ... ON CONFLICT so the whole day loads in one round trip. The load
+// runs in one transaction; when a row fails the configured ErrorPolicy decides
+// whether the whole day is rolled back or the row is skipped. Cancelling ctx
+// interrupts the copy or merge and rolls the load back.
+func SaveToTable(ctx context.Context, records []*ZacksRecord, table string) (*LoadStats, error) {
+	policy := ConfiguredErrorPolicy()
+
+	conn, err := pgx.Connect(ctx, viper.GetString("database.url"))
+	if err != nil {
+		log.Error().Err(err).Msg("Could not connect to database")
+		return nil, err
+	}
+	defer conn.Close(ctx)
+
+	stats := &LoadStats{}
+
+	// only records with a composite figi can be saved; when the same security
+	// appears more than once the last record wins
+	seen := make(map[string]int, len(records))
+	toLoad := make([]*ZacksRecord, 0, len(records))
+	for _, r := range records {
+		if r.CompositeFigi == "" {
+			stats.Skipped++
+			continue
+		}
+
+		key := r.CompositeFigi + r.EventDate.Format("2006-01-02")
+		if idx, ok := seen[key]; ok {
+			log.Warn().Str("CompositeFigi", r.CompositeFigi).Str("Ticker", r.Ticker).Msg("duplicate record in screen; keeping last")
+			toLoad[idx] = r
+			stats.Duplicates++
+			continue
+		}
+
+		seen[key] = len(toLoad)
+		toLoad = append(toLoad, r)
+	}
+
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		log.Error().Err(err).Msg("could not begin transaction")
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err = tx.Exec(ctx, fmt.Sprintf(`CREATE TEMPORARY TABLE %s (LIKE %s INCLUDING DEFAULTS) ON COMMIT DROP`, stagingTable, pgx.Identifier{table}.Sanitize())); err != nil {
+		log.Error().Err(err).Msg("could not create staging table")
+		return nil, err
+	}
+
+	if err = copyToStaging(ctx, tx, toLoad, policy, stats); err != nil {
+		return stats, err
+	}
+
+	if err = mergeStaging(ctx, tx, table, toLoad, policy, stats); err != nil {
+		return stats, err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		log.Error().Err(err).Msg("could not commit transaction")
+		return stats, err
+	}
+
+	log.Info().Str("Table", table).Int64("Inserted", stats.Inserted).Int64("Updated", stats.Updated).Int64("Skipped", stats.Skipped).Int64("Duplicates", stats.Duplicates).Int("Failed", len(stats.Failed)).Msg("records saved to DB")
+	return stats, nil
+}
+
+// copyToStaging copies records into the staging table. The bulk copy runs in a
+// savepoint; if it fails and the policy is ErrorPolicySkip each record is
+// retried in its own savepoint so only the bad rows are dropped.
+func copyToStaging(ctx context.Context, tx pgx.Tx, records []*ZacksRecord, policy ErrorPolicy, stats *LoadStats) error {
+	rows := make([][]interface{}, len(records))
+	for idx, r := range records {
+		rows[idx] = r.dbValues()
+	}
+
+	savepoint, err := tx.Begin(ctx)
+	if err != nil {
+		log.Error().Err(err).Msg("could not create savepoint")
+		return err
+	}
+
+	copied, err := savepoint.CopyFrom(ctx, pgx.Identifier{stagingTable}, zacksFinancialsColumns, pgx.CopyFromRows(rows))
+	if err == nil {
+		log.Debug().Int64("NumRecords", copied).Msg("copied records into staging table")
+		return savepoint.Commit(ctx)
+	}
+
+	savepoint.Rollback(ctx)
+
+	if policy != ErrorPolicySkip {
+		log.Error().Err(err).Msg("copy into staging table failed; rolling back import")
 		return err
 	}
-	defer conn.Close(context.Background())
 
-	cnt := 0
+	log.Warn().Err(err).Msg("copy into staging table failed; retrying row by row")
+
+	for idx, r := range records {
+		savepoint, err := tx.Begin(ctx)
+		if err != nil {
+			log.Error().Err(err).Msg("could not create savepoint")
+			return err
+		}
+
+		if _, err := savepoint.CopyFrom(ctx, pgx.Identifier{stagingTable}, zacksFinancialsColumns, pgx.CopyFromRows(rows[idx:idx+1])); err != nil {
+			savepoint.Rollback(ctx)
+			stats.fail(r.Ticker, r.CompositeFigi, err)
+			continue
+		}
+
+		if err := savepoint.Commit(ctx); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// mergeStaging upserts the staging table into table. The merge runs in a
+// savepoint; if it fails and the policy is ErrorPolicySkip each record that
+// reached the staging table is merged in its own savepoint so only the rows
+// the table rejects are dropped.
+func mergeStaging(ctx context.Context, tx pgx.Tx, table string, records []*ZacksRecord, policy ErrorPolicy, stats *LoadStats) error {
+	savepoint, err := tx.Begin(ctx)
+	if err != nil {
+		log.Error().Err(err).Msg("could not create savepoint")
+		return err
+	}
+
+	err = savepoint.QueryRow(ctx, mergeStagingSQL(table, false)).Scan(&stats.Inserted, &stats.Updated)
+	if err == nil {
+		return savepoint.Commit(ctx)
+	}
+
+	savepoint.Rollback(ctx)
+
+	if policy != ErrorPolicySkip || ctx.Err() != nil {
+		log.Error().Err(err).Str("Table", table).Msg("merge staging table failed; rolling back import")
+		return err
+	}
+
+	log.Warn().Err(err).Str("Table", table).Msg("merge staging table failed; retrying row by row")
+
+	failed := make(map[string]bool, len(stats.Failed))
+	for _, row := range stats.Failed {
+		failed[row.CompositeFigi] = true
+	}
+
+	rowSQL := mergeStagingSQL(table, true)
 	for _, r := range records {
-		if r.CompositeFigi != "" {
-			_, err = conn.Exec(context.Background(),
-				`INSERT INTO zacks_financials (
-				"ticker",
-				"composite_figi",
-				"event_date",
-				"in_sp500",
-				"month_of_fiscal_yr_end",
-				"optionable",
-				"sector",
-				"industry",
-				"shares_outstanding_mil",
-				"market_cap_mil",
-				"avg_volume",
-				"wk_high_52",
-				"wk_low_52",
-				"price_as_percent_of_52wk_hl",
-				"beta",
-				"percent_price_change_1wk",
-				"percent_price_change_4wk",
-				"percent_price_change_12wk",
-				"percent_price_change_ytd",
-				"relative_price_change",
-				"zacks_rank",
-				"zacks_rank_change_indicator",
-				"zacks_industry_rank",
-				"value_score",
-				"growth_score",
-				"momentum_score",
-				"vgm_score",
-				"current_avg_broker_rec",
-				"num_brokers_in_rating",
-				"num_rating_strong_buy_or_buy",
-				"percent_rating_strong_buy_or_buy",
-				"num_rating_hold",
-				"num_rating_strong_sell_or_sell",
-				"percent_rating_strong_sell_or_sell",
-				"percent_rating_change_4wk",
-				"industry_rank_of_abr",
-				"rank_in_industry_of_abr",
-				"change_in_avg_rec",
-				"number_rating_upgrades",
-				"number_rating_downgrades",
-				"percent_rating_hold",
-				"percent_rating_upgrades",
-				"percent_rating_downgrades",
-				"average_target_price",
-				"earnings_esp",
-				"last_eps_surprise_percent",
-				"previous_eps_surprise_percent",
-				"avg_eps_surprise_last_4_qtrs",
-				"actual_eps_used_in_surprise_dollars_per_share",
-				"last_qtr_eps",
-				"last_reported_qtr_date",
-				"last_yr_eps_f0_before_nri",
-				"twelve_mo_trailing_eps",
-				"last_reported_fiscal_yr",
-				"last_eps_report_date",
-				"next_eps_report_date",
-				"percent_change_q0_est",
-				"percent_change_q2_est",
-				"percent_change_f1_est",
-				"percent_change_q1_est",
-				"percent_change_f2_est",
-				"percent_change_lt_growth_est",
-				"q0_consensus_est_last_completed_fiscal_qtr",
-				"number_of_analysts_in_q0_consensus",
-				"q1_consensus_est",
-				"number_of_analysts_in_q1_consensus",
-				"stdev_q1_q1_consensus_ratio",
-				"q2_consensus_est_next_fiscal_qtr",
-				"number_of_analysts_in_q2_consensus",
-				"stdev_q2_q2_consensus_ratio",
-				"f0_consensus_est",
-				"number_of_analysts_in_f0_consensus",
-				"f1_consensus_est",
-				"number_of_analysts_in_f1_consensus",
-				"stdev_f1_f1_consensus_ratio",
-				"f2_consensus_est",
-				"number_of_analysts_in_f2_consensus",
-				"five_yr_hist_eps_growth",
-				"long_term_growth_consensus_est",
-				"percent_change_eps",
-				"last_yrs_growth",
-				"this_yrs_est_growth",
-				"percent_ratio_of_q1_q0",
-				"percent_ratio_of_q1_prior_yr_q1_actual_q",
-				"sales_growth",
-				"five_yr_historical_sales_growth",
-				"q1_consensus_sales_est_mil",
-				"f1_consensus_sales_est_mil",
-				"pe_trailing_12_months",
-				"pe_f1",
-				"pe_f2",
-				"peg_ratio",
-				"price_to_cash_flow",
-				"price_to_sales",
-				"price_to_book",
-				"current_roe_ttm",
-				"current_roi_ttm",
-				"roi_5_yr_avg",
-				"current_roa_ttm",
-				"roa_5_yr_avg",
-				"market_value_to_number_analysts",
-				"annual_sales_mil",
-				"cost_of_goods_sold_mil",
-				"ebitda_mil",
-				"ebit_mil",
-				"pretax_income_mil",
-				"net_income_mil",
-				"cash_flow_mil",
-				"net_income_growth_f0_f_neg1",
-				"twelve_mo_net_income_current_to_last_percent",
-				"twelve_mo_net_income_current_1q_to_last_1q_percent",
-				"div_yield_percent",
-				"five_yr_div_yield_percent",
-				"five_yr_hist_div_growth_percent",
-				"dividend",
-				"net_margin_percent",
-				"turnover",
-				"operating_margin_12_mo_percent",
-				"inventory_turnover",
-				"asset_utilization",
-				"receivables_mil",
-				"intangibles_mil",
-				"inventory_mil",
-				"current_assets_mil",
-				"current_liabilities_mil",
-				"long_term_debt_mil",
-				"preferred_equity_mil",
-				"common_equity_mil",
-				"book_value",
-				"debt_to_total_capital",
-				"debt_to_equity_ratio",
-				"current_ratio",
-				"quick_ratio",
-				"cash_ratio"
-			) VALUES (
-				$1,
-				$2,
-				$3,
-				$4,
-				$5,
-				$6,
-				$7,
-				$8,
-				$9,
-				$10,
-				$11,
-				$12,
-				$13,
-				$14,
-				$15,
-				$16,
-				$17,
-				$18,
-				$19,
-				$20,
-				$21,
-				$22,
-				$23,
-				$24,
-				$25,
-				$26,
-				$27,
-				$28,
-				$29,
-				$30,
-				$31,
-				$32,
-				$33,
-				$34,
-				$35,
-				$36,
-				$37,
-				$38,
-				$39,
-				$40,
-				$41,
-				$42,
-				$43,
-				$44,
-				$45,
-				$46,
-				$47,
-				$48,
-				$49,
-				$50,
-				$51,
-				$52,
-				$53,
-				$54,
-				$55,
-				$56,
-				$57,
-				$58,
-				$59,
-				$60,
-				$61,
-				$62,
-				$63,
-				$64,
-				$65,
-				$66,
-				$67,
-				$68,
-				$69,
-				$70,
-				$71,
-				$72,
-				$73,
-				$74,
-				$75,
-				$76,
-				$77,
-				$78,
-				$79,
-				$80,
-				$81,
-				$82,
-				$83,
-				$84,
-				$85,
-				$86,
-				$87,
-				$88,
-				$89,
-				$90,
-				$91,
-				$92,
-				$93,
-				$94,
-				$95,
-				$96,
-				$97,
-				$98,
-				$99,
-				$100,
-				$101,
-				$102,
-				$103,
-				$104,
-				$105,
-				$106,
-				$107,
-				$108,
-				$109,
-				$110,
-				$111,
-				$112,
-				$113,
-				$114,
-				$115,
-				$116,
-				$117,
-				$118,
-				$119,
-				$120,
-				$121,
-				$122,
-				$123,
-				$124,
-				$125,
-				$126,
-				$127,
-				$128,
-				$129,
-				$130,
-				$131,
-				$132,
-				$133,
-				$134
-			) ON CONFLICT ON CONSTRAINT zacks_financials_pkey
-			DO UPDATE SET
-				ticker = EXCLUDED.ticker,
-				composite_figi = EXCLUDED.composite_figi,
-				event_date = EXCLUDED.event_date,
-				in_sp500 = EXCLUDED.in_sp500,
-				month_of_fiscal_yr_end = EXCLUDED.month_of_fiscal_yr_end,
-				optionable = EXCLUDED.optionable,
-				sector = EXCLUDED.sector,
-				industry = EXCLUDED.industry,
-				shares_outstanding_mil = EXCLUDED.shares_outstanding_mil,
-				market_cap_mil = EXCLUDED.market_cap_mil,
-				avg_volume = EXCLUDED.avg_volume,
-				wk_high_52 = EXCLUDED.wk_high_52,
-				wk_low_52 = EXCLUDED.wk_low_52,
-				price_as_percent_of_52wk_hl = EXCLUDED.price_as_percent_of_52wk_hl,
-				beta = EXCLUDED.beta,
-				percent_price_change_1wk = EXCLUDED.percent_price_change_1wk,
-				percent_price_change_4wk = EXCLUDED.percent_price_change_4wk,
-				percent_price_change_12wk = EXCLUDED.percent_price_change_12wk,
-				percent_price_change_ytd = EXCLUDED.percent_price_change_ytd,
-				relative_price_change = EXCLUDED.relative_price_change,
-				zacks_rank = EXCLUDED.zacks_rank,
-				zacks_rank_change_indicator = EXCLUDED.zacks_rank_change_indicator,
-				zacks_industry_rank = EXCLUDED.zacks_industry_rank,
-				value_score = EXCLUDED.value_score,
-				growth_score = EXCLUDED.growth_score,
-				momentum_score = EXCLUDED.momentum_score,
-				vgm_score = EXCLUDED.vgm_score,
-				current_avg_broker_rec = EXCLUDED.current_avg_broker_rec,
-				num_brokers_in_rating = EXCLUDED.num_brokers_in_rating,
-				num_rating_strong_buy_or_buy = EXCLUDED.num_rating_strong_buy_or_buy,
-				percent_rating_strong_buy_or_buy = EXCLUDED.percent_rating_strong_buy_or_buy,
-				num_rating_hold = EXCLUDED.num_rating_hold,
-				num_rating_strong_sell_or_sell = EXCLUDED.num_rating_strong_sell_or_sell,
-				percent_rating_strong_sell_or_sell = EXCLUDED.percent_rating_strong_sell_or_sell,
-				percent_rating_change_4wk = EXCLUDED.percent_rating_change_4wk,
-				industry_rank_of_abr = EXCLUDED.industry_rank_of_abr,
-				rank_in_industry_of_abr = EXCLUDED.rank_in_industry_of_abr,
-				change_in_avg_rec = EXCLUDED.change_in_avg_rec,
-				number_rating_upgrades = EXCLUDED.number_rating_upgrades,
-				number_rating_downgrades = EXCLUDED.number_rating_downgrades,
-				percent_rating_hold = EXCLUDED.percent_rating_hold,
-				percent_rating_upgrades = EXCLUDED.percent_rating_upgrades,
-				percent_rating_downgrades = EXCLUDED.percent_rating_downgrades,
-				average_target_price = EXCLUDED.average_target_price,
-				earnings_esp = EXCLUDED.earnings_esp,
-				last_eps_surprise_percent = EXCLUDED.last_eps_surprise_percent,
-				previous_eps_surprise_percent = EXCLUDED.previous_eps_surprise_percent,
-				avg_eps_surprise_last_4_qtrs = EXCLUDED.avg_eps_surprise_last_4_qtrs,
-				actual_eps_used_in_surprise_dollars_per_share = EXCLUDED.actual_eps_used_in_surprise_dollars_per_share,
-				last_qtr_eps = EXCLUDED.last_qtr_eps,
-				last_reported_qtr_date = EXCLUDED.last_reported_qtr_date,
-				last_yr_eps_f0_before_nri = EXCLUDED.last_yr_eps_f0_before_nri,
-				twelve_mo_trailing_eps = EXCLUDED.twelve_mo_trailing_eps,
-				last_reported_fiscal_yr = EXCLUDED.last_reported_fiscal_yr,
-				last_eps_report_date = EXCLUDED.last_eps_report_date,
-				next_eps_report_date = EXCLUDED.next_eps_report_date,
-				percent_change_q0_est = EXCLUDED.percent_change_q0_est,
-				percent_change_q2_est = EXCLUDED.percent_change_q2_est,
-				percent_change_f1_est = EXCLUDED.percent_change_f1_est,
-				percent_change_q1_est = EXCLUDED.percent_change_q1_est,
-				percent_change_f2_est = EXCLUDED.percent_change_f2_est,
-				percent_change_lt_growth_est = EXCLUDED.percent_change_lt_growth_est,
-				q0_consensus_est_last_completed_fiscal_qtr = EXCLUDED.q0_consensus_est_last_completed_fiscal_qtr,
-				number_of_analysts_in_q0_consensus = EXCLUDED.number_of_analysts_in_q0_consensus,
-				q1_consensus_est = EXCLUDED.q1_consensus_est,
-				number_of_analysts_in_q1_consensus = EXCLUDED.number_of_analysts_in_q1_consensus,
-				stdev_q1_q1_consensus_ratio = EXCLUDED.stdev_q1_q1_consensus_ratio,
-				q2_consensus_est_next_fiscal_qtr = EXCLUDED.q2_consensus_est_next_fiscal_qtr,
-				number_of_analysts_in_q2_consensus = EXCLUDED.number_of_analysts_in_q2_consensus,
-				stdev_q2_q2_consensus_ratio = EXCLUDED.stdev_q2_q2_consensus_ratio,
-				f0_consensus_est = EXCLUDED.f0_consensus_est,
-				number_of_analysts_in_f0_consensus = EXCLUDED.number_of_analysts_in_f0_consensus,
-				f1_consensus_est = EXCLUDED.f1_consensus_est,
-				number_of_analysts_in_f1_consensus = EXCLUDED.number_of_analysts_in_f1_consensus,
-				stdev_f1_f1_consensus_ratio = EXCLUDED.stdev_f1_f1_consensus_ratio,
-				f2_consensus_est = EXCLUDED.f2_consensus_est,
-				number_of_analysts_in_f2_consensus = EXCLUDED.number_of_analysts_in_f2_consensus,
-				five_yr_hist_eps_growth = EXCLUDED.five_yr_hist_eps_growth,
-				long_term_growth_consensus_est = EXCLUDED.long_term_growth_consensus_est,
-				percent_change_eps = EXCLUDED.percent_change_eps,
-				last_yrs_growth = EXCLUDED.last_yrs_growth,
-				this_yrs_est_growth = EXCLUDED.this_yrs_est_growth,
-				percent_ratio_of_q1_q0 = EXCLUDED.percent_ratio_of_q1_q0,
-				percent_ratio_of_q1_prior_yr_q1_actual_q = EXCLUDED.percent_ratio_of_q1_prior_yr_q1_actual_q,
-				sales_growth = EXCLUDED.sales_growth,
-				five_yr_historical_sales_growth = EXCLUDED.five_yr_historical_sales_growth,
-				q1_consensus_sales_est_mil = EXCLUDED.q1_consensus_sales_est_mil,
-				f1_consensus_sales_est_mil = EXCLUDED.f1_consensus_sales_est_mil,
-				pe_trailing_12_months = EXCLUDED.pe_trailing_12_months,
-				pe_f1 = EXCLUDED.pe_f1,
-				pe_f2 = EXCLUDED.pe_f2,
-				peg_ratio = EXCLUDED.peg_ratio,
-				price_to_cash_flow = EXCLUDED.price_to_cash_flow,
-				price_to_sales = EXCLUDED.price_to_sales,
-				price_to_book = EXCLUDED.price_to_book,
-				current_roe_ttm = EXCLUDED.current_roe_ttm,
-				current_roi_ttm = EXCLUDED.current_roi_ttm,
-				roi_5_yr_avg = EXCLUDED.roi_5_yr_avg,
-				current_roa_ttm = EXCLUDED.current_roa_ttm,
-				roa_5_yr_avg = EXCLUDED.roa_5_yr_avg,
-				market_value_to_number_analysts = EXCLUDED.market_value_to_number_analysts,
-				annual_sales_mil = EXCLUDED.annual_sales_mil,
-				cost_of_goods_sold_mil = EXCLUDED.cost_of_goods_sold_mil,
-				ebitda_mil = EXCLUDED.ebitda_mil,
-				ebit_mil = EXCLUDED.ebit_mil,
-				pretax_income_mil = EXCLUDED.pretax_income_mil,
-				net_income_mil = EXCLUDED.net_income_mil,
-				cash_flow_mil = EXCLUDED.cash_flow_mil,
-				net_income_growth_f0_f_neg1 = EXCLUDED.net_income_growth_f0_f_neg1,
-				twelve_mo_net_income_current_to_last_percent = EXCLUDED.twelve_mo_net_income_current_to_last_percent,
-				twelve_mo_net_income_current_1q_to_last_1q_percent = EXCLUDED.twelve_mo_net_income_current_1q_to_last_1q_percent,
-				div_yield_percent = EXCLUDED.div_yield_percent,
-				five_yr_div_yield_percent = EXCLUDED.five_yr_div_yield_percent,
-				five_yr_hist_div_growth_percent = EXCLUDED.five_yr_hist_div_growth_percent,
-				dividend = EXCLUDED.dividend,
-				net_margin_percent = EXCLUDED.net_margin_percent,
-				turnover = EXCLUDED.turnover,
-				operating_margin_12_mo_percent = EXCLUDED.operating_margin_12_mo_percent,
-				inventory_turnover = EXCLUDED.inventory_turnover,
-				asset_utilization = EXCLUDED.asset_utilization,
-				receivables_mil = EXCLUDED.receivables_mil,
-				intangibles_mil = EXCLUDED.intangibles_mil,
-				inventory_mil = EXCLUDED.inventory_mil,
-				current_assets_mil = EXCLUDED.current_assets_mil,
-				current_liabilities_mil = EXCLUDED.current_liabilities_mil,
-				long_term_debt_mil = EXCLUDED.long_term_debt_mil,
-				preferred_equity_mil = EXCLUDED.preferred_equity_mil,
-				common_equity_mil = EXCLUDED.common_equity_mil,
-				book_value = EXCLUDED.book_value,
-				debt_to_total_capital = EXCLUDED.debt_to_total_capital,
-				debt_to_equity_ratio = EXCLUDED.debt_to_equity_ratio,
-				current_ratio = EXCLUDED.current_ratio,
-				quick_ratio = EXCLUDED.quick_ratio,
-				cash_ratio = EXCLUDED.cash_ratio
-			`,
-				r.Ticker, r.CompositeFigi, r.EventDate,
-				r.InSp500, r.MonthOfFiscalYrEnd, r.Optionable, r.Sector,
-				r.Industry, r.SharesOutstandingMil, r.MarketCapMil,
-				r.AvgVolume, r.WkHigh52, r.WkLow52,
-				r.PriceAsPercentOf52wkHighLow, r.Beta, r.PercentPriceChange1Wk,
-				r.PercentPriceChange4Wk, r.PercentPriceChange12Wk,
-				r.PercentPriceChangeYtd, r.RelativePriceChange, r.ZacksRank,
-				r.ZacksRankChangeIndicator, r.ZacksIndustryRank, r.ValueScore,
-				r.GrowthScore, r.MomentumScore, r.VgmScore, r.CurrentAvgBrokerRec,
-				r.NumBrokersInRating, r.NumRatingStrongBuyOrBuy,
-				r.PercentRatingStrongBuyOrBuy, r.NumRatingHold,
-				r.NumRatingStrongSellOrSell, r.PercentRatingStrongSellOrSell,
-				r.PercentRatingChange4Wk, r.IndustryRankOfAbr,
-				r.RankInIndustryOfAbr, r.ChangeInAvgRec, r.NumberRatingUpgrades,
-				r.NumberRatingDowngrades, r.PercentRatingHold,
-				r.PercentRatingUpgrades, r.PercentRatingDowngrades,
-				r.AverageTargetPrice, r.EarningsEsp, r.LastEpsSurprisePercent,
-				r.PreviousEpsSurprisePercent, r.AvgEpsSurpriseLast4Qtrs,
-				r.ActualEpsUsedInSurpriseDollarsPerShare, r.LastQtrEps,
-				r.LastReportedQtrDate, r.LastYrEpsF0BeforeNri,
-				r.TwelveMoTrailingEps, r.LastReportedFiscalYr,
-				r.LastEpsReportDate, r.NextEpsReportDate, r.PercentChangeQ0Est,
-				r.PercentChangeQ2Est, r.PercentChangeF1Est, r.PercentChangeQ1Est,
-				r.PercentChangeF2Est, r.PercentChangeLtGrowthEst,
-				r.Q0ConsensusEstLastCompletedFiscalQtr,
-				r.NumberOfAnalystsInQ0Consensus, r.Q1ConsensusEst,
-				r.NumberOfAnalystsInQ1Consensus, r.StdevQ1Q1ConsensusRatio,
-				r.Q2ConsensusEstNextFiscalQtr, r.NumberOfAnalystsInQ2Consensus,
-				r.StdevQ2Q2ConsensusRatio, r.F0ConsensusEst,
-				r.NumberOfAnalystsInF0Consensus, r.F1ConsensusEst,
-				r.NumberOfAnalystsInF1Consensus, r.StdevF1F1ConsensusRatio,
-				r.F2ConsensusEst, r.NumberOfAnalystsInF2Consensus,
-				r.FiveYrHistEpsGrowth, r.LongTermGrowthConsensusEst,
-				r.PercentChangeEps, r.LastYrsGrowth, r.ThisYrsEstGrowth,
-				r.PercentRatioOfQ1Q0, r.PercentRatioOfQ1PriorYrQ1ActualQ,
-				r.SalesGrowth, r.FiveYrHistoricalSalesGrowth,
-				r.Q1ConsensusSalesEstMil, r.F1ConsensusSalesEstMil,
-				r.PeTrailing12Months, r.PeF1, r.PeF2, r.PegRatio,
-				r.PriceToCashFlow, r.PriceToSales, r.PriceToBook,
-				r.CurrentRoeTtm, r.CurrentRoiTtm, r.Roi5YrAvg, r.CurrentRoaTtm,
-				r.Roa5YrAvg, r.MarketValueToNumberAnalysts, r.AnnualSalesMil,
-				r.CostOfGoodsSoldMil, r.EbitdaMil, r.EbitMil, r.PretaxIncomeMil,
-				r.NetIncomeMil, r.CashFlowMil, r.NetIncomeGrowthF0FNeg1,
-				r.TwelveMoNetIncomeCurrentToLastPercent,
-				r.TwelveMoNetIncomeCurrent1qToLast1qPercent, r.DivYieldPercent,
-				r.FiveYrDivYieldPercent, r.FiveYrHistDivGrowthPercent,
-				r.Dividend, r.NetMarginPercent, r.Turnover,
-				r.OperatingMargin12MoPercent, r.InventoryTurnover,
-				r.AssetUtilization, r.ReceivablesMil, r.IntangiblesMil,
-				r.InventoryMil, r.CurrentAssetsMil,
-				r.CurrentLiabilitiesMil, r.LongTermDebtMil,
-				r.PreferredEquityMil, r.CommonEquityMil, r.BookValue,
-				r.DebtToTotalCapital, r.DebtToEquityRatio,
-				r.CurrentRatio, r.QuickRatio, r.CashRatio)
-			if err != nil {
-				log.Warn().Err(err).Str("CompositeFigi", r.CompositeFigi).Str("Ticker", r.Ticker).Int("ZacksRank", r.ZacksRank).Msg("insert into db failed")
-				return err
-			} else {
-				cnt++
+		if failed[r.CompositeFigi] {
+			continue
+		}
+
+		savepoint, err := tx.Begin(ctx)
+		if err != nil {
+			log.Error().Err(err).Msg("could not create savepoint")
+			return err
+		}
+
+		var inserted, updated int64
+		if err := savepoint.QueryRow(ctx, rowSQL, r.CompositeFigi, r.EventDate).Scan(&inserted, &updated); err != nil {
+			savepoint.Rollback(ctx)
+			if ctx.Err() != nil {
+				return ctx.Err()
 			}
+			stats.fail(r.Ticker, r.CompositeFigi, err)
+			continue
 		}
+
+		if err := savepoint.Commit(ctx); err != nil {
+			return err
+		}
+		stats.Inserted += inserted
+		stats.Updated += updated
 	}
 
-	log.Info().Int("NumRecords", cnt).Msg("records saved to DB")
 	return nil
 }
 
-func (balanceSheetList BalanceSheetList) SaveToDB(ctx context.Context, conn *pgx.Conn) {
+// ExistingEventDates returns the set of event dates (YYYY-MM-DD) already loaded into zacks_financials
+func ExistingEventDates(ctx context.Context) (map[string]bool, error) {
+	conn, err := pgx.Connect(ctx, viper.GetString("database.url"))
+	if err != nil {
+		log.Error().Err(err).Msg("Could not connect to database")
+		return nil, err
+	}
+	defer conn.Close(ctx)
+
+	rows, err := conn.Query(ctx, "SELECT DISTINCT event_date FROM zacks_financials")
+	if err != nil {
+		log.Error().Err(err).Msg("could not query existing event dates")
+		return nil, err
+	}
+	defer rows.Close()
+
+	dates := make(map[string]bool)
+	for rows.Next() {
+		var eventDate time.Time
+		if err := rows.Scan(&eventDate); err != nil {
+			return nil, err
+		}
+		dates[eventDate.Format("2006-01-02")] = true
+	}
+
+	return dates, rows.Err()
+}
+
+// mergeStagingSQL builds the statement that upserts the staging table into
+// table and counts inserted vs. updated rows (xmax is 0 for rows created by
+// the insert). With singleRow only the row whose composite figi and event
+// date are given as $1 and $2 is merged.
+func mergeStagingSQL(table string, singleRow bool) string {
+	quoted := make([]string, len(zacksFinancialsColumns))
+	updates := make([]string, len(zacksFinancialsColumns))
+	for idx, col := range zacksFinancialsColumns {
+		quoted[idx] = fmt.Sprintf(`"%s"`, col)
+		updates[idx] = fmt.Sprintf(`"%s" = EXCLUDED."%s"`, col, col)
+	}
+	colList := strings.Join(quoted, ", ")
+
+	where := ""
+	if singleRow {
+		where = " WHERE composite_figi = $1 AND event_date = $2"
+	}
+
+	return fmt.Sprintf(`WITH merged AS (
+		INSERT INTO %s (%s)
+		SELECT %s FROM %s%s
+		ON CONFLICT (composite_figi, event_date)
+		DO UPDATE SET %s
+		RETURNING (xmax = 0) AS inserted
+	)
+	SELECT count(*) FILTER (WHERE inserted), count(*) FILTER (WHERE NOT inserted) FROM merged`,
+		pgx.Identifier{table}.Sanitize(), colList, colList, stagingTable, where, strings.Join(updates, ", "))
+}
+
+// SaveToDB updates the current assets, current liabilities and working capital
+// of matching fundamentals rows. All updates run in a single transaction and
+// failing rows are handled according to the configured ErrorPolicy.
+func (balanceSheetList BalanceSheetList) SaveToDB(ctx context.Context, conn *pgx.Conn) (*LoadStats, error) {
+	policy := ConfiguredErrorPolicy()
+
 	// build a list of all active records that have composite figi's
 	tickerMap := make(map[string]*Ticker)
 
 	// build figi map
-	rows, err := conn.Query(context.Background(), "SELECT ticker, name, composite_figi FROM assets WHERE active='t' AND composite_figi IS NOT NULL")
+	rows, err := conn.Query(ctx, "SELECT ticker, name, composite_figi FROM assets WHERE active='t' AND composite_figi IS NOT NULL")
 	if err != nil {
 		log.Error().Err(err).Msg("Failed to retrieve tickers from database")
+		return nil, err
 	}
 
 	for rows.Next() {
@@ -557,13 +900,53 @@ func (balanceSheetList BalanceSheetList) SaveToDB(ctx context.Context, conn *pgx
 		tickerMap[ticker.Ticker] = &ticker
 	}
 
+	stats := &LoadStats{}
+
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		log.Error().Err(err).Msg("could not begin transaction")
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
 	// save each balance sheet to database
 	for _, r := range balanceSheetList {
-		if ticker, ok := tickerMap[r.Ticker]; ok {
-			r.CompositeFigi = ticker.CompositeFigi
-			if _, err := conn.Exec(ctx, "UPDATE fundamentals SET curr_assets=$1, curr_liabilities=$2, working_capital=$3 WHERE composite_figi=$4 AND calendar_date=$5 AND dim=$6", r.TotalCurrentAssets, r.TotalCurrentLiabilities, r.TotalCurrentAssets-r.TotalCurrentLiabilities, r.CompositeFigi, r.CalendarDate, r.Dimension); err != nil {
-				log.Error().Err(err).Str("Ticker", r.Ticker).Str("CompositeFIGI", r.CompositeFigi).Msg("error updating database")
+		ticker, ok := tickerMap[r.Ticker]
+		if !ok {
+			stats.Skipped++
+			continue
+		}
+
+		r.CompositeFigi = ticker.CompositeFigi
+
+		savepoint, err := tx.Begin(ctx)
+		if err != nil {
+			log.Error().Err(err).Msg("could not create savepoint")
+			return stats, err
+		}
+
+		tag, err := savepoint.Exec(ctx, "UPDATE fundamentals SET curr_assets=$1, curr_liabilities=$2, working_capital=$3 WHERE composite_figi=$4 AND calendar_date=$5 AND dim=$6", r.TotalCurrentAssets, r.TotalCurrentLiabilities, r.TotalCurrentAssets-r.TotalCurrentLiabilities, r.CompositeFigi, r.CalendarDate, r.Dimension)
+		if err != nil {
+			savepoint.Rollback(ctx)
+			if policy != ErrorPolicySkip {
+				log.Error().Err(err).Str("Ticker", r.Ticker).Str("CompositeFIGI", r.CompositeFigi).Msg("error updating database; rolling back import")
+				return stats, err
 			}
+			stats.fail(r.Ticker, r.CompositeFigi, err)
+			continue
 		}
+
+		if err := savepoint.Commit(ctx); err != nil {
+			return stats, err
+		}
+		stats.Updated += tag.RowsAffected()
 	}
+
+	if err := tx.Commit(ctx); err != nil {
+		log.Error().Err(err).Msg("could not commit transaction")
+		return stats, err
+	}
+
+	log.Info().Int64("Updated", stats.Updated).Int64("Skipped", stats.Skipped).Int("Failed", len(stats.Failed)).Msg("balance sheets saved to DB")
+	return stats, nil
 }
@@ -0,0 +1,302 @@
+/*
+Copyright 2022
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package zacks
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/viper"
+)
+
+// SnapshotTicker identifies a ticker that was added to or dropped from the screen
+type SnapshotTicker struct {
+	Ticker      string `json:"ticker"`
+	CompanyName string `json:"company_name"`
+	ZacksRank   *int   `json:"zacks_rank"`
+}
+
+// RankChange is a change in Zacks Rank; an upgrade moves towards 1 (strong buy)
+type RankChange struct {
+	Ticker      string `json:"ticker"`
+	CompanyName string `json:"company_name"`
+	From        int    `json:"from"`
+	To          int    `json:"to"`
+}
+
+// ScoreChange is a change in one of the value, growth, momentum or VGM style scores
+type ScoreChange struct {
+	Ticker      string `json:"ticker"`
+	CompanyName string `json:"company_name"`
+	Score       string `json:"score"`
+	From        string `json:"from"`
+	To          string `json:"to"`
+}
+
+// EstimateMove is the change in a consensus EPS estimate
+type EstimateMove struct {
+	Ticker        string  `json:"ticker"`
+	CompanyName   string  `json:"company_name"`
+	Estimate      string  `json:"estimate"`
+	From          float64 `json:"from"`
+	To            float64 `json:"to"`
+	PercentChange float64 `json:"percent_change"`
+}
+
+// SnapshotDiff describes what changed between two ratings snapshots
+type SnapshotDiff struct {
+	From           string            `json:"from"`
+	To             string            `json:"to"`
+	Added          []*SnapshotTicker `json:"added"`
+	Dropped        []*SnapshotTicker `json:"dropped"`
+	Upgrades       []*RankChange     `json:"upgrades"`
+	Downgrades     []*RankChange     `json:"downgrades"`
+	ScoreChanges   []*ScoreChange    `json:"score_changes"`
+	EstimateMovers []*EstimateMove   `json:"estimate_movers"`
+}
+
+// DiffRow is one line of a SnapshotDiff when flattened for table or CSV output
+type DiffRow struct {
+	Change      string `csv:"change"`
+	Ticker      string `csv:"ticker"`
+	CompanyName string `csv:"company_name"`
+	Field       string `csv:"field"`
+	From        string `csv:"from"`
+	To          string `csv:"to"`
+	Percent     string `csv:"percent_change"`
+}
+
+// LoadSnapshot returns the records of one ratings snapshot and its event
+// date. When fn is empty the snapshot is read from zacks_financials for
+// dateStr; otherwise fn is read as a parquet file written by ParquetSink or as
+// a screener CSV, and dateStr (may be empty) overrides the date of the file.
+// An empty snapshot is an error wrapping ErrEmptySnapshot, since diffing it
+// would report every ticker as added or dropped.
+func LoadSnapshot(ctx context.Context, dateStr, fn string) (string, []*ZacksRecord, error) {
+	if fn == "" {
+		if dateStr == "" {
+			return "", nil, ErrDateNotFound
+		}
+		records, err := LoadFromDB(ctx, dateStr)
+		if err != nil {
+			return "", nil, err
+		}
+		if len(records) == 0 {
+			return "", nil, fmt.Errorf("no ratings for %s: %w", dateStr, ErrEmptySnapshot)
+		}
+		return dateStr, records, nil
+	}
+
+	if strings.EqualFold(filepath.Ext(fn), ".parquet") {
+		records, err := LoadFromParquet(fn)
+		if err != nil {
+			return "", nil, err
+		}
+		if len(records) == 0 {
+			return "", nil, fmt.Errorf("no ratings in %s: %w", fn, ErrEmptySnapshot)
+		}
+		if dateStr == "" {
+			dateStr = records[0].EventDateStr
+		}
+		return dateStr, records, nil
+	}
+
+	data, err := os.ReadFile(fn)
+	if err != nil {
+		log.Error().Err(err).Str("FileName", fn).Msg("could not read input file")
+		return "", nil, err
+	}
+
+	date, _, err := DefaultDateResolver(dateStr).Resolve(&Batch{Filename: filepath.Base(fn), Path: fn})
+	if err != nil {
+		return "", nil, err
+	}
+	dateStr = date.Format("2006-01-02")
+
+	records, err := LoadRatings(data, dateStr, RatingsOptions{NAAsZero: viper.GetBool("zacks.na_as_zero")})
+	if err != nil {
+		return "", nil, err
+	}
+	if len(records) == 0 {
+		return "", nil, fmt.Errorf("no ratings in %s: %w", fn, ErrEmptySnapshot)
+	}
+	return dateStr, records, nil
+}
+
+// DiffSnapshots compares two snapshots by ticker. At most top estimate movers,
+// ranked by absolute percent change, are reported; top <= 0 reports all of them.
+func DiffSnapshots(fromDate string, from []*ZacksRecord, toDate string, to []*ZacksRecord, top int) *SnapshotDiff {
+	diff := &SnapshotDiff{
+		From:           fromDate,
+		To:             toDate,
+		Added:          make([]*SnapshotTicker, 0),
+		Dropped:        make([]*SnapshotTicker, 0),
+		Upgrades:       make([]*RankChange, 0),
+		Downgrades:     make([]*RankChange, 0),
+		ScoreChanges:   make([]*ScoreChange, 0),
+		EstimateMovers: make([]*EstimateMove, 0),
+	}
+
+	before := recordsByTicker(from)
+	after := recordsByTicker(to)
+
+	for _, ticker := range sortedTickers(after) {
+		curr := after[ticker]
+		prev, ok := before[ticker]
+		if !ok {
+			diff.Added = append(diff.Added, &SnapshotTicker{Ticker: ticker, CompanyName: curr.CompanyName, ZacksRank: curr.ZacksRank})
+			continue
+		}
+
+		if prev.ZacksRank != nil && curr.ZacksRank != nil && *prev.ZacksRank != *curr.ZacksRank {
+			change := &RankChange{Ticker: ticker, CompanyName: curr.CompanyName, From: *prev.ZacksRank, To: *curr.ZacksRank}
+			if change.To < change.From {
+				diff.Upgrades = append(diff.Upgrades, change)
+			} else {
+				diff.Downgrades = append(diff.Downgrades, change)
+			}
+		}
+
+		scores := []struct {
+			name       string
+			prev, curr string
+		}{
+			{"value_score", prev.ValueScore, curr.ValueScore},
+			{"growth_score", prev.GrowthScore, curr.GrowthScore},
+			{"momentum_score", prev.MomentumScore, curr.MomentumScore},
+			{"vgm_score", prev.VgmScore, curr.VgmScore},
+		}
+		for _, score := range scores {
+			if score.prev != score.curr {
+				diff.ScoreChanges = append(diff.ScoreChanges, &ScoreChange{
+					Ticker:      ticker,
+					CompanyName: curr.CompanyName,
+					Score:       score.name,
+					From:        score.prev,
+					To:          score.curr,
+				})
+			}
+		}
+
+		estimates := []struct {
+			name       string
+			prev, curr *float32
+		}{
+			{"q1_consensus_est", prev.Q1ConsensusEst, curr.Q1ConsensusEst},
+			{"f1_consensus_est", prev.F1ConsensusEst, curr.F1ConsensusEst},
+			{"f2_consensus_est", prev.F2ConsensusEst, curr.F2ConsensusEst},
+		}
+		for _, est := range estimates {
+			// a percent change from zero is meaningless
+			if est.prev == nil || est.curr == nil || *est.prev == 0 || *est.prev == *est.curr {
+				continue
+			}
+			prevVal, currVal := float64(*est.prev), float64(*est.curr)
+			diff.EstimateMovers = append(diff.EstimateMovers, &EstimateMove{
+				Ticker:        ticker,
+				CompanyName:   curr.CompanyName,
+				Estimate:      est.name,
+				From:          prevVal,
+				To:            currVal,
+				PercentChange: (currVal - prevVal) / math.Abs(prevVal) * 100,
+			})
+		}
+	}
+
+	for _, ticker := range sortedTickers(before) {
+		if _, ok := after[ticker]; !ok {
+			prev := before[ticker]
+			diff.Dropped = append(diff.Dropped, &SnapshotTicker{Ticker: ticker, CompanyName: prev.CompanyName, ZacksRank: prev.ZacksRank})
+		}
+	}
+
+	sort.SliceStable(diff.EstimateMovers, func(i, j int) bool {
+		return math.Abs(diff.EstimateMovers[i].PercentChange) > math.Abs(diff.EstimateMovers[j].PercentChange)
+	})
+	if top > 0 && len(diff.EstimateMovers) > top {
+		diff.EstimateMovers = diff.EstimateMovers[:top]
+	}
+
+	return diff
+}
+
+// Rows flattens the diff into one row per change
+func (diff *SnapshotDiff) Rows() []*DiffRow {
+	rows := make([]*DiffRow, 0)
+
+	rank := func(rank *int) string {
+		if rank == nil {
+			return ""
+		}
+		return strconv.Itoa(*rank)
+	}
+
+	for _, t := range diff.Added {
+		rows = append(rows, &DiffRow{Change: "added", Ticker: t.Ticker, CompanyName: t.CompanyName, Field: "zacks_rank", To: rank(t.ZacksRank)})
+	}
+	for _, t := range diff.Dropped {
+		rows = append(rows, &DiffRow{Change: "dropped", Ticker: t.Ticker, CompanyName: t.CompanyName, Field: "zacks_rank", From: rank(t.ZacksRank)})
+	}
+	for _, c := range diff.Upgrades {
+		rows = append(rows, &DiffRow{Change: "upgrade", Ticker: c.Ticker, CompanyName: c.CompanyName, Field: "zacks_rank", From: strconv.Itoa(c.From), To: strconv.Itoa(c.To)})
+	}
+	for _, c := range diff.Downgrades {
+		rows = append(rows, &DiffRow{Change: "downgrade", Ticker: c.Ticker, CompanyName: c.CompanyName, Field: "zacks_rank", From: strconv.Itoa(c.From), To: strconv.Itoa(c.To)})
+	}
+	for _, c := range diff.ScoreChanges {
+		rows = append(rows, &DiffRow{Change: "score", Ticker: c.Ticker, CompanyName: c.CompanyName, Field: c.Score, From: c.From, To: c.To})
+	}
+	for _, m := range diff.EstimateMovers {
+		rows = append(rows, &DiffRow{
+			Change:      "estimate",
+			Ticker:      m.Ticker,
+			CompanyName: m.CompanyName,
+			Field:       m.Estimate,
+			From:        strconv.FormatFloat(m.From, 'f', -1, 64),
+			To:          strconv.FormatFloat(m.To, 'f', -1, 64),
+			Percent:     fmt.Sprintf("%.2f", m.PercentChange),
+		})
+	}
+
+	return rows
+}
+
+func recordsByTicker(records []*ZacksRecord) map[string]*ZacksRecord {
+	byTicker := make(map[string]*ZacksRecord, len(records))
+	for _, r := range records {
+		if r.Ticker != "" {
+			byTicker[r.Ticker] = r
+		}
+	}
+	return byTicker
+}
+
+func sortedTickers(byTicker map[string]*ZacksRecord) []string {
+	tickers := make([]string, 0, len(byTicker))
+	for ticker := range byTicker {
+		tickers = append(tickers, ticker)
+	}
+	sort.Strings(tickers)
+	return tickers
+}
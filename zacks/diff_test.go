@@ -0,0 +1,95 @@
+/*
+Copyright 2022
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package zacks
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestDiffSnapshots(t *testing.T) {
+	rank := func(v int) *int { return &v }
+	est := func(v float32) *float32 { return &v }
+
+	tests := []struct {
+		name string
+		from []*ZacksRecord
+		to   []*ZacksRecord
+		top  int
+		want []string
+	}{
+		{
+			name: "unchanged",
+			from: []*ZacksRecord{{Ticker: "AAPL", ZacksRank: rank(3), ValueScore: "C"}},
+			to:   []*ZacksRecord{{Ticker: "AAPL", ZacksRank: rank(3), ValueScore: "C"}},
+			want: []string{},
+		},
+		{
+			name: "added and dropped",
+			from: []*ZacksRecord{{Ticker: "AAPL", ZacksRank: rank(3)}, {Ticker: "IBM", ZacksRank: rank(4)}},
+			to:   []*ZacksRecord{{Ticker: "AAPL", ZacksRank: rank(3)}, {Ticker: "MSFT", ZacksRank: rank(2)}},
+			want: []string{"added MSFT zacks_rank  -> 2", "dropped IBM zacks_rank 4 -> "},
+		},
+		{
+			name: "rank changes",
+			from: []*ZacksRecord{{Ticker: "AAPL", ZacksRank: rank(3)}, {Ticker: "MSFT", ZacksRank: rank(2)}, {Ticker: "EXMP"}},
+			to:   []*ZacksRecord{{Ticker: "AAPL", ZacksRank: rank(1)}, {Ticker: "MSFT", ZacksRank: rank(4)}, {Ticker: "EXMP", ZacksRank: rank(3)}},
+			want: []string{"upgrade AAPL zacks_rank 3 -> 1", "downgrade MSFT zacks_rank 2 -> 4"},
+		},
+		{
+			name: "score changes",
+			from: []*ZacksRecord{{Ticker: "AAPL", ValueScore: "C", VgmScore: "B"}},
+			to:   []*ZacksRecord{{Ticker: "AAPL", ValueScore: "B", VgmScore: ""}},
+			want: []string{"score AAPL value_score C -> B", "score AAPL vgm_score B -> "},
+		},
+		{
+			name: "estimate movers are ranked and truncated",
+			from: []*ZacksRecord{
+				{Ticker: "AAPL", Q1ConsensusEst: est(1), F1ConsensusEst: est(0), F2ConsensusEst: est(-2)},
+				{Ticker: "MSFT", Q1ConsensusEst: est(2)},
+			},
+			to: []*ZacksRecord{
+				{Ticker: "AAPL", Q1ConsensusEst: est(1.1), F1ConsensusEst: est(1), F2ConsensusEst: est(-1)},
+				{Ticker: "MSFT", Q1ConsensusEst: est(1)},
+			},
+			top:  2,
+			want: []string{"estimate AAPL f2_consensus_est -2 -> -1 (50.00)", "estimate MSFT q1_consensus_est 2 -> 1 (-50.00)"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diff := DiffSnapshots("2024-05-02", tt.from, "2024-05-03", tt.to, tt.top)
+			if diff.From != "2024-05-02" || diff.To != "2024-05-03" {
+				t.Errorf("expected dates 2024-05-02 to 2024-05-03, got %s to %s", diff.From, diff.To)
+			}
+
+			got := make([]string, 0)
+			for _, row := range diff.Rows() {
+				line := fmt.Sprintf("%s %s %s %s -> %s", row.Change, row.Ticker, row.Field, row.From, row.To)
+				if row.Percent != "" {
+					line += fmt.Sprintf(" (%s)", row.Percent)
+				}
+				got = append(got, line)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
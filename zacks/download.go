@@ -17,24 +17,80 @@ limitations under the License.
 package zacks
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"os"
+	"path/filepath"
+	"strings"
 
-	"github.com/penny-vault/import-zacks-rank/common"
 	"github.com/playwright-community/playwright-go"
 	"github.com/rs/zerolog/log"
 	"github.com/spf13/viper"
 )
 
+const csvButtonSelector = "#screener_table_wrapper > div.dt-buttons > a.dt-button.buttons-csv.buttons-html5"
+
+// ScreenDownload is the CSV export of one saved screen
+type ScreenDownload struct {
+	Screen   *Screen
+	Data     []byte
+	Filename string
+}
+
 // Download authenticates with the zacks webpage and downloads the results of the stock screen
-// it returns the downloaded bytes, filename, and any errors that occur
-func Download() (fileData []byte, outputFilename string, err error) {
-	page, context, browser, pw := common.StartPlaywright(viper.GetBool("playwright.headless"))
+// it returns the downloaded bytes, filename, and any errors that occur. The
+// browser is always shut down before Download returns, including when ctx is
+// cancelled or its deadline passes.
+func Download(ctx context.Context) (fileData []byte, outputFilename string, err error) {
+	downloads, err := DownloadScreens(ctx, []*Screen{DefaultScreen()})
+	if err != nil {
+		return nil, "", err
+	}
+	return downloads[0].Data, downloads[0].Filename, nil
+}
 
-	EnsureLoggedIn(page)
+// DownloadScreens logs in once and downloads each screen in turn in the same
+// browser session. A screen that fails does not stop the others; the returned
+// error names every failed screen and the successful downloads are returned
+// alongside it. When ctx is done the browser is closed, the screens not yet
+// downloaded are skipped and the context's error is returned.
+func DownloadScreens(ctx context.Context, screens []*Screen) ([]*ScreenDownload, error) {
+	page, evidence, stop, err := startLoggedIn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer stop()
+
+	downloads := make([]*ScreenDownload, 0, len(screens))
+	var errs []error
+	for _, screen := range screens {
+		if err := ctx.Err(); err != nil {
+			errs = append(errs, err)
+			break
+		}
 
-	log.Info().Msg("Load stock screener page")
+		data, filename, err := downloadScreen(page, screen, len(screens) > 1)
+		if err != nil {
+			captureEvidence(ctx, evidence, "download-"+screen.Name, err)
+			err = contextErr(ctx, err)
+			log.Error().Err(err).Str("Screen", screen.Name).Msg("screen download failed")
+			errs = append(errs, fmt.Errorf("screen %s: %w", screen.Name, err))
+			continue
+		}
 
-	if _, err = page.Goto(STOCK_SCREENER_URL, playwright.PageGotoOptions{
+		log.Info().Str("Screen", screen.Name).Str("FileName", filename).Int("Size", len(data)).Msg("downloaded screen")
+		downloads = append(downloads, &ScreenDownload{Screen: screen, Data: data, Filename: filename})
+	}
+
+	return downloads, errors.Join(errs...)
+}
+
+// downloadScreen runs a saved screen from the stock screener page and returns the CSV export
+func downloadScreen(page playwright.Page, screen *Screen, multiple bool) (fileData []byte, outputFilename string, err error) {
+	log.Info().Str("Screen", screen.Name).Msg("Load stock screener page")
+
+	if _, err = page.Goto(StockScreenerURL(), playwright.PageGotoOptions{
 		WaitUntil: playwright.WaitUntilStateNetworkidle,
 	}); err != nil {
 		log.Error().Err(err).Msg("could not load stock screener page")
@@ -50,13 +106,9 @@ func Download() (fileData []byte, outputFilename string, err error) {
 		return
 	}
 
-	log.Info().Msg("run the saved stock screen")
-
-	// navigate to our saved screen
+	log.Info().Str("Screen", screen.String()).Msg("run the saved stock screen")
 
-	log.Info().Msg("clicking run button")
-
-	if err = frame.Locator("#btn_run_137005").Click(); err != nil {
+	if err = runButton(frame, screen).Click(); err != nil {
 		log.Error().Err(err).Msg("click run button failed")
 		return
 	}
@@ -64,15 +116,14 @@ func Download() (fileData []byte, outputFilename string, err error) {
 	log.Info().Msg("button clicked")
 
 	// wait for the screen to finish running
-	if err = frame.Locator("#screener_table_wrapper > div.dt-buttons > a.dt-button.buttons-csv.buttons-html5").WaitFor(); err != nil {
+	if err = frame.Locator(csvButtonSelector).WaitFor(); err != nil {
 		log.Error().Err(err).Msg("wait for 'csv' download selector failed")
 		return
 	}
 
-	zacksPdfFn := viper.GetString("zacks.pdf")
-	if zacksPdfFn != "" {
+	if zacksPdfFn := pdfFilename(screen, multiple); zacksPdfFn != "" {
 		log.Info().Str("fn", zacksPdfFn).Msg("saving PDF")
-		if _, err = page.PDF(playwright.PagePdfOptions{
+		if _, err := page.PDF(playwright.PagePdfOptions{
 			Path: playwright.String(zacksPdfFn),
 		}); err != nil {
 			log.Error().Err(err).Msg("could not save page to PDF")
@@ -81,23 +132,59 @@ func Download() (fileData []byte, outputFilename string, err error) {
 
 	var download playwright.Download
 	if download, err = page.ExpectDownload(func() error {
-		return frame.Locator("#screener_table_wrapper > div.dt-buttons > a.dt-button.buttons-csv.buttons-html5").Click()
+		return frame.Locator(csvButtonSelector).Click()
 	}); err != nil {
 		log.Error().Err(err).Msg("download failed")
+		return
 	}
 
 	var path string
 	if path, err = download.Path(); err != nil {
 		log.Error().Err(err).Msg("download failed")
-	} else {
-		outputFilename = download.SuggestedFilename()
-		fileData, err = os.ReadFile(path)
-		if err != nil {
-			log.Error().Err(err).Msg("reading data failed")
-			return
-		}
+		return
 	}
 
-	common.StopPlaywright(page, context, browser, pw)
+	outputFilename = download.SuggestedFilename()
+	if fileData, err = os.ReadFile(path); err != nil {
+		log.Error().Err(err).Msg("reading data failed")
+	}
 	return
 }
+
+// runButton locates the run button of a saved screen
+func runButton(frame playwright.FrameLocator, screen *Screen) playwright.Locator {
+	return savedScreenButton(frame, "btn_run_", screen.ID, screen.Title)
+}
+
+// savedScreenButton locates a button on the My Screens tab whose id is prefix
+// followed by the screen id. When id is 0 the screen is found by the row whose
+// name matches title.
+func savedScreenButton(frame playwright.FrameLocator, prefix string, id int, title string) playwright.Locator {
+	if id != 0 {
+		return frame.Locator(fmt.Sprintf("#%s%d", prefix, id))
+	}
+
+	name := frame.GetByText(title, playwright.FrameLocatorGetByTextOptions{Exact: playwright.Bool(true)})
+	return frame.Locator("tr", playwright.FrameLocatorLocatorOptions{Has: name}).Locator(fmt.Sprintf("[id^=%s]", prefix)).First()
+}
+
+// pdfFilename returns where to save a PDF of the screen results; when several
+// screens are downloaded the screen name is added to the configured filename
+func pdfFilename(screen *Screen, multiple bool) string {
+	fn := viper.GetString("zacks.pdf")
+	if fn == "" || !multiple {
+		return fn
+	}
+
+	ext := filepath.Ext(fn)
+	return fmt.Sprintf("%s-%s%s", strings.TrimSuffix(fn, ext), screen.Name, ext)
+}
+
+// contextErr attributes err to ctx when ctx is done, since a playwright call
+// fails with a closed browser error once CloseOnDone has closed the browser
+func contextErr(ctx context.Context, err error) error {
+	if ctxErr := ctx.Err(); err != nil && ctxErr != nil {
+		return fmt.Errorf("%w: %v", ctxErr, err)
+	}
+	return err
+}
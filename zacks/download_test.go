@@ -0,0 +1,135 @@
+/*
+Copyright 2022
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package zacks
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/penny-vault/import-zacks-rank/zacks/fakezacks"
+)
+
+func TestDownload(t *testing.T) {
+	fake := startFakeZacks(t)
+
+	data, filename, err := Download(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if filename != "zacks_custom_screen_2024-05-03.csv" {
+		t.Errorf("filename = %q", filename)
+	}
+	records, err := LoadRatings(data, "2024-05-03", RatingsOptions{})
+	if err != nil {
+		t.Fatalf("downloaded data does not parse: %v", err)
+	}
+	if len(records) != 3 {
+		t.Errorf("downloaded %d records, want 3", len(records))
+	}
+	if fake.Downloads() != 1 {
+		t.Errorf("fake served %d downloads, want 1", fake.Downloads())
+	}
+}
+
+func TestDownloadScreens(t *testing.T) {
+	fake := startFakeZacks(t)
+	fake.AddScreen(200, "Value Picks", []byte("Ticker\nAAPL\n"), "value.csv")
+
+	screens := []*Screen{
+		DefaultScreen(),
+		// found by its title rather than its id
+		{Name: "value", Title: "Value Picks", Schema: SchemaRaw, Dataset: "value"},
+	}
+
+	downloads, err := DownloadScreens(context.Background(), screens)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(downloads) != 2 {
+		t.Fatalf("got %d downloads, want 2", len(downloads))
+	}
+	if downloads[1].Screen.Name != "value" || downloads[1].Filename != "value.csv" || string(downloads[1].Data) != "Ticker\nAAPL\n" {
+		t.Errorf("second download = %s %q %q", downloads[1].Screen.Name, downloads[1].Filename, downloads[1].Data)
+	}
+	if fake.Logins() != 1 {
+		t.Errorf("fake recorded %d logins, want 1 for both screens", fake.Logins())
+	}
+}
+
+func TestScreensSourceRetriesOutage(t *testing.T) {
+	fake := startFakeZacks(t)
+	// the homepage of the first attempt fails, then the site recovers
+	fake.FailRequests = 1
+
+	backoff := &Backoff{Initial: 400 * time.Millisecond, Max: 400 * time.Millisecond}
+	source := &ScreensSource{Screens: []*Screen{DefaultScreen()}, MaxRetries: 3, Backoff: backoff}
+
+	start := time.Now()
+	batches, err := source.FetchAll(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(batches) != 1 || len(batches[0].Data) == 0 {
+		t.Fatalf("expected one batch with data, got %d batches", len(batches))
+	}
+	// jitter takes off at most half of the delay
+	if elapsed := time.Since(start); elapsed < backoff.Initial/2 {
+		t.Errorf("retried after %s, want a backoff of at least %s", elapsed, backoff.Initial/2)
+	}
+	if got := fake.Requests("/"); got < 2 {
+		t.Errorf("homepage requested %d times, want a retry", got)
+	}
+	if fake.Logins() != 1 {
+		t.Errorf("fake recorded %d logins, want 1", fake.Logins())
+	}
+}
+
+func TestScreensSourceGivesUp(t *testing.T) {
+	tests := []struct {
+		name     string
+		setup    func(fake *fakezacks.Server)
+		want     error
+		attempts int
+	}{
+		{"outage is retried", func(fake *fakezacks.Server) { fake.Outage = true }, ErrSiteUnavailable, 3},
+		{"bad credentials are not", func(fake *fakezacks.Server) { fake.Password = "changed" }, ErrBadCredentials, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := startFakeZacks(t)
+			tt.setup(fake)
+
+			source := &ScreensSource{Screens: []*Screen{DefaultScreen()}, MaxRetries: 3, Backoff: &Backoff{Initial: 10 * time.Millisecond}}
+			batches, err := source.FetchAll(context.Background())
+			if len(batches) != 0 {
+				t.Errorf("expected no batches, got %d", len(batches))
+			}
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("error = %v, want %v", err, tt.want)
+			}
+			if got := fake.Requests("/"); got != tt.attempts {
+				t.Errorf("homepage requested %d times, want %d", got, tt.attempts)
+			}
+		})
+	}
+}
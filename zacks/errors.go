@@ -0,0 +1,42 @@
+/*
+Copyright 2022
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package zacks
+
+import "errors"
+
+var (
+	ErrNoSource        = errors.New("pipeline has no source")
+	ErrNoRatings       = errors.New("no ratings returned")
+	ErrDateNotFound    = errors.New("could not determine event date; pass --date or name the file zacks_custom_screen_YYYY-MM-DD")
+	ErrDateConflict    = errors.New("event date strategies disagree")
+	ErrColumnDrift     = errors.New("screener columns do not match ZacksRecord")
+	ErrNoParquetOutput = errors.New("no parquet file to upload, ParquetSink must run first")
+	ErrUnknownScreen   = errors.New("no screen with that name is configured")
+	ErrEmptySnapshot   = errors.New("snapshot has no rows")
+	// ErrUnknownSchemaVersion is returned for ratings parquet files written
+	// with a schema this version cannot read
+	ErrUnknownSchemaVersion = errors.New("unsupported ratings parquet schema version")
+)
+
+// Login failures returned by EnsureLoggedIn; see Retryable
+var (
+	ErrBadCredentials      = errors.New("zacks.com rejected the username or password")
+	ErrSubscriptionExpired = errors.New("zacks.com subscription has expired")
+	ErrBotChallenge        = errors.New("zacks.com showed a captcha or bot challenge")
+	ErrSiteUnavailable     = errors.New("zacks.com is unavailable")
+	ErrLoginFailed         = errors.New("could not confirm login to zacks.com")
+)
@@ -0,0 +1,256 @@
+/*
+Copyright 2022
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package zacks
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// DateStrategy determines the event date of a batch from one source of evidence.
+// Resolve returns ok=false when the strategy has nothing to say about the batch.
+type DateStrategy interface {
+	Name() string
+	Resolve(batch *Batch) (date time.Time, ok bool, err error)
+}
+
+// DateResolver tries each strategy in order. The first authoritative strategy
+// that finds a date wins, and every other authoritative strategy that finds a
+// date must agree with it. Fallback strategies are only consulted when no
+// authoritative strategy found a date.
+type DateResolver struct {
+	Authoritative []DateStrategy
+	Fallback      []DateStrategy
+}
+
+// DefaultDateResolver checks, in order, an explicit date (may be empty), the
+// download filename, archive path conventions, the file modification time and
+// finally the last trading day
+func DefaultDateResolver(explicit string) *DateResolver {
+	return &DateResolver{
+		Authoritative: []DateStrategy{
+			&ExplicitDate{Value: explicit},
+			&FilenameDate{},
+			&ArchivePathDate{},
+		},
+		Fallback: []DateStrategy{
+			&FileModTimeDate{},
+			&LastTradingDay{},
+		},
+	}
+}
+
+// BackfillDateResolver only trusts an explicit date, the download filename and
+// archive path conventions. The modification time and last trading day
+// fallbacks would give many archived files the same date, so they are left out.
+func BackfillDateResolver(explicit string) *DateResolver {
+	return &DateResolver{
+		Authoritative: []DateStrategy{
+			&ExplicitDate{Value: explicit},
+			&FilenameDate{},
+			&ArchivePathDate{},
+		},
+	}
+}
+
+// Resolve returns the event date of the batch and the name of the strategy that determined it
+func (resolver *DateResolver) Resolve(batch *Batch) (time.Time, string, error) {
+	var (
+		winner     time.Time
+		winnerName string
+	)
+
+	for _, strategy := range resolver.Authoritative {
+		date, ok, err := strategy.Resolve(batch)
+		if err != nil {
+			return time.Time{}, strategy.Name(), fmt.Errorf("date strategy %s: %w", strategy.Name(), err)
+		}
+		if !ok {
+			continue
+		}
+
+		if winnerName == "" {
+			winner = date
+			winnerName = strategy.Name()
+			continue
+		}
+
+		if !date.Equal(winner) {
+			log.Error().Str("FileName", batch.Filename).
+				Str(winnerName, winner.Format("2006-01-02")).
+				Str(strategy.Name(), date.Format("2006-01-02")).
+				Msg("event date strategies disagree")
+			return time.Time{}, winnerName, fmt.Errorf("%w: %s says %s but %s says %s", ErrDateConflict,
+				winnerName, winner.Format("2006-01-02"), strategy.Name(), date.Format("2006-01-02"))
+		}
+	}
+
+	if winnerName != "" {
+		log.Info().Str("Strategy", winnerName).Str("EventDate", winner.Format("2006-01-02")).Msg("resolved event date")
+		return winner, winnerName, nil
+	}
+
+	for _, strategy := range resolver.Fallback {
+		date, ok, err := strategy.Resolve(batch)
+		if err != nil {
+			return time.Time{}, strategy.Name(), fmt.Errorf("date strategy %s: %w", strategy.Name(), err)
+		}
+		if ok {
+			log.Warn().Str("Strategy", strategy.Name()).Str("EventDate", date.Format("2006-01-02")).Str("FileName", batch.Filename).Msg("event date not found in filename; using fallback")
+			return date, strategy.Name(), nil
+		}
+	}
+
+	log.Error().Str("FileName", batch.Filename).Msg("could not determine event date")
+	return time.Time{}, "", ErrDateNotFound
+}
+
+// ExplicitDate uses a date supplied by the user, e.g. with --date
+type ExplicitDate struct {
+	Value string
+}
+
+func (s *ExplicitDate) Name() string { return "flag" }
+
+func (s *ExplicitDate) Resolve(batch *Batch) (time.Time, bool, error) {
+	if s.Value == "" {
+		return time.Time{}, false, nil
+	}
+
+	date, err := time.Parse("2006-01-02", s.Value)
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("expected YYYY-MM-DD: %w", err)
+	}
+	return date, true, nil
+}
+
+var screenFilenameRegex = regexp.MustCompile(`zacks_custom_screen_(\d{4}-\d{2}-\d{2})`)
+
+// FilenameDate reads the date out of the zacks_custom_screen_YYYY-MM-DD download name
+type FilenameDate struct{}
+
+func (s *FilenameDate) Name() string { return "filename" }
+
+func (s *FilenameDate) Resolve(batch *Batch) (time.Time, bool, error) {
+	match := screenFilenameRegex.FindStringSubmatch(batch.Filename)
+	if match == nil {
+		return time.Time{}, false, nil
+	}
+
+	date, err := time.Parse("2006-01-02", match[1])
+	if err != nil {
+		return time.Time{}, false, err
+	}
+	return date, true, nil
+}
+
+var archivePathRegexes = []struct {
+	regex  *regexp.Regexp
+	layout string
+}{
+	// zacks-20240503.parquet as written by ParquetSink
+	{regexp.MustCompile(`zacks-(\d{8})\b`), "20060102"},
+	// hive style partitions, e.g. date=2024-05-03
+	{regexp.MustCompile(`date=(\d{4}-\d{2}-\d{2})`), "2006-01-02"},
+	// dated directories, e.g. 2024/05/03/
+	{regexp.MustCompile(`(\d{4}/\d{2}/\d{2})/`), "2006/01/02"},
+}
+
+// ArchivePathDate recognizes the directory and file naming conventions used when archiving screens
+type ArchivePathDate struct{}
+
+func (s *ArchivePathDate) Name() string { return "archive-path" }
+
+func (s *ArchivePathDate) Resolve(batch *Batch) (time.Time, bool, error) {
+	path := batch.Path
+	if path == "" {
+		path = batch.Filename
+	}
+	path = strings.ReplaceAll(path, "\\", "/")
+
+	for _, convention := range archivePathRegexes {
+		if match := convention.regex.FindStringSubmatch(path); match != nil {
+			date, err := time.Parse(convention.layout, match[1])
+			if err != nil {
+				return time.Time{}, false, err
+			}
+			return date, true, nil
+		}
+	}
+
+	return time.Time{}, false, nil
+}
+
+// FileModTimeDate uses the modification date of the file the batch was read from
+type FileModTimeDate struct{}
+
+func (s *FileModTimeDate) Name() string { return "file-mtime" }
+
+func (s *FileModTimeDate) Resolve(batch *Batch) (time.Time, bool, error) {
+	if batch.Path == "" {
+		return time.Time{}, false, nil
+	}
+
+	info, err := os.Stat(batch.Path)
+	if err != nil {
+		return time.Time{}, false, nil
+	}
+
+	return truncateToDate(info.ModTime().In(marketTimezone())), true, nil
+}
+
+// LastTradingDay assumes the screen was run on the most recent weekday in New
+// York. Exchange holidays are not taken into account.
+type LastTradingDay struct {
+	// Now is used in place of time.Now when set
+	Now func() time.Time
+}
+
+func (s *LastTradingDay) Name() string { return "last-trading-day" }
+
+func (s *LastTradingDay) Resolve(batch *Batch) (time.Time, bool, error) {
+	now := time.Now
+	if s.Now != nil {
+		now = s.Now
+	}
+
+	day := now().In(marketTimezone())
+	for day.Weekday() == time.Saturday || day.Weekday() == time.Sunday {
+		day = day.AddDate(0, 0, -1)
+	}
+
+	return truncateToDate(day), true, nil
+}
+
+func marketTimezone() *time.Location {
+	tz, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		log.Warn().Err(err).Msg("could not load America/New_York timezone; using UTC")
+		return time.UTC
+	}
+	return tz
+}
+
+// truncateToDate returns midnight UTC of the calendar day of t, matching what time.Parse produces for a date
+func truncateToDate(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
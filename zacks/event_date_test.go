@@ -0,0 +1,73 @@
+/*
+Copyright 2022
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package zacks
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestDateResolver(t *testing.T) {
+	saturday := func() time.Time { return time.Date(2024, 5, 4, 15, 0, 0, 0, time.UTC) }
+
+	tests := []struct {
+		name     string
+		resolver *DateResolver
+		batch    Batch
+		want     string
+		strategy string
+		err      error
+	}{
+		{"download filename", DefaultDateResolver(""), Batch{Filename: "zacks_custom_screen_2024-05-03.csv"}, "2024-05-03", "filename", nil},
+		{"explicit date", DefaultDateResolver("2024-05-02"), Batch{Filename: "screen.csv"}, "2024-05-02", "flag", nil},
+		{"explicit date agrees", DefaultDateResolver("2024-05-03"), Batch{Filename: "zacks_custom_screen_2024-05-03.csv"}, "2024-05-03", "flag", nil},
+		{"explicit date conflicts", DefaultDateResolver("2024-05-02"), Batch{Filename: "zacks_custom_screen_2024-05-03.csv"}, "", "", ErrDateConflict},
+		{"parquet archive name", BackfillDateResolver(""), Batch{Filename: "zacks-20240503.parquet"}, "2024-05-03", "archive-path", nil},
+		{"hive partition", BackfillDateResolver(""), Batch{Filename: "part-0.csv", Path: "dataset=ratings/date=2024-05-03/part-0.csv"}, "2024-05-03", "archive-path", nil},
+		{"dated directory", BackfillDateResolver(""), Batch{Filename: "screen.csv", Path: `archive\2024\05\03\screen.csv`}, "2024-05-03", "archive-path", nil},
+		{"backfill has no fallback", BackfillDateResolver(""), Batch{Filename: "screen.csv"}, "", "", ErrDateNotFound},
+		{"last trading day skips the weekend", &DateResolver{Fallback: []DateStrategy{&LastTradingDay{Now: saturday}}}, Batch{Filename: "screen.csv"}, "2024-05-03", "last-trading-day", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			date, strategy, err := tt.resolver.Resolve(&tt.batch)
+			if tt.err != nil {
+				if !errors.Is(err, tt.err) {
+					t.Fatalf("expected %v, got %v", tt.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := date.Format("2006-01-02"); got != tt.want {
+				t.Errorf("expected %s, got %s", tt.want, got)
+			}
+			if strategy != tt.strategy {
+				t.Errorf("expected strategy %s, got %s", tt.strategy, strategy)
+			}
+		})
+	}
+}
+
+func TestExplicitDateRejectsBadFormat(t *testing.T) {
+	if _, _, err := DefaultDateResolver("05/03/2024").Resolve(&Batch{Filename: "screen.csv"}); err == nil {
+		t.Fatal("expected an error for a date that is not YYYY-MM-DD")
+	}
+}
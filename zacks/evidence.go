@@ -0,0 +1,44 @@
+/*
+Copyright 2022
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package zacks
+
+import (
+	"context"
+
+	"github.com/penny-vault/import-zacks-rank/common"
+	"github.com/penny-vault/import-zacks-rank/storage"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/viper"
+)
+
+// captureEvidence saves an evidence bundle for step, which failed with cause,
+// and archives it when evidence.upload is set. Nothing is captured once ctx
+// is done because the browser has already been closed.
+func captureEvidence(ctx context.Context, evidence *common.Evidence, step string, cause error) {
+	if evidence == nil || ctx.Err() != nil {
+		return
+	}
+
+	dir, _ := evidence.Capture(step, cause)
+	if dir == "" || !viper.GetBool("evidence.upload") {
+		return
+	}
+
+	if _, err := storage.ArchiveEvidence(ctx, dir); err != nil {
+		log.Error().Err(err).Str("Dir", dir).Msg("could not archive failure evidence")
+	}
+}
@@ -0,0 +1,330 @@
+/*
+Copyright 2022
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package zacks
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/apache/arrow-go/v18/arrow"
+	"github.com/apache/arrow-go/v18/arrow/array"
+	"github.com/apache/arrow-go/v18/arrow/ipc"
+	"github.com/apache/arrow-go/v18/arrow/memory"
+	"github.com/rs/zerolog/log"
+)
+
+var ErrUnknownExportFormat = errors.New("unknown export format; expected parquet, csv, jsonl or arrow")
+
+// Exporter writes records to a file in one format
+type Exporter interface {
+	Format() string
+	Export(records []*ZacksRecord, fn string) error
+}
+
+// NewExporter returns the exporter for format (parquet, csv, jsonl or arrow)
+func NewExporter(format string) (Exporter, error) {
+	switch strings.ToLower(format) {
+	case "parquet":
+		return &ParquetExporter{}, nil
+	case "csv":
+		return &CSVExporter{}, nil
+	case "jsonl", "ndjson":
+		return &JSONLExporter{}, nil
+	case "arrow", "ipc":
+		return &ArrowExporter{}, nil
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnknownExportFormat, format)
+	}
+}
+
+// exportField is a ZacksRecord field included in exports, named by its json tag
+type exportField struct {
+	Name  string
+	Index int
+	Type  reflect.Type
+}
+
+// exportFields returns the exported fields of ZacksRecord in declaration order
+func exportFields() []*exportField {
+	recordType := reflect.TypeOf(ZacksRecord{})
+	fields := make([]*exportField, 0, recordType.NumField())
+	for ii := 0; ii < recordType.NumField(); ii++ {
+		field := recordType.Field(ii)
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		if name == "" || name == "-" {
+			continue
+		}
+		fields = append(fields, &exportField{Name: name, Index: ii, Type: field.Type})
+	}
+	return fields
+}
+
+// ParquetExporter writes the same parquet schema as the archived files
+type ParquetExporter struct{}
+
+func (e *ParquetExporter) Format() string { return "parquet" }
+
+func (e *ParquetExporter) Export(records []*ZacksRecord, fn string) error {
+	return SaveToParquet(records, fn)
+}
+
+// CSVExporter writes a CSV with the snake_case json names as headers. Missing
+// values are written as empty cells.
+type CSVExporter struct{}
+
+func (e *CSVExporter) Format() string { return "csv" }
+
+func (e *CSVExporter) Export(records []*ZacksRecord, fn string) error {
+	fh, err := os.Create(fn)
+	if err != nil {
+		log.Error().Err(err).Str("FileName", fn).Msg("cannot create local file")
+		return err
+	}
+	defer fh.Close()
+
+	fields := exportFields()
+	writer := csv.NewWriter(fh)
+
+	row := make([]string, len(fields))
+	for idx, field := range fields {
+		row[idx] = field.Name
+	}
+	if err := writer.Write(row); err != nil {
+		return err
+	}
+
+	for _, r := range records {
+		value := reflect.ValueOf(r).Elem()
+		for idx, field := range fields {
+			row[idx] = formatExportValue(value.Field(field.Index))
+		}
+		if err := writer.Write(row); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return err
+	}
+	return fh.Close()
+}
+
+func formatExportValue(value reflect.Value) string {
+	if value.Kind() == reflect.Ptr {
+		if value.IsNil() {
+			return ""
+		}
+		value = value.Elem()
+	}
+
+	switch value.Kind() {
+	case reflect.String:
+		return value.String()
+	case reflect.Bool:
+		return strconv.FormatBool(value.Bool())
+	case reflect.Float32:
+		return strconv.FormatFloat(value.Float(), 'f', -1, 32)
+	case reflect.Float64:
+		return strconv.FormatFloat(value.Float(), 'f', -1, 64)
+	case reflect.Int, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(value.Int(), 10)
+	default:
+		return fmt.Sprint(value.Interface())
+	}
+}
+
+// JSONLExporter writes one JSON object per record per line
+type JSONLExporter struct{}
+
+func (e *JSONLExporter) Format() string { return "jsonl" }
+
+func (e *JSONLExporter) Export(records []*ZacksRecord, fn string) error {
+	fh, err := os.Create(fn)
+	if err != nil {
+		log.Error().Err(err).Str("FileName", fn).Msg("cannot create local file")
+		return err
+	}
+	defer fh.Close()
+
+	buf := bufio.NewWriter(fh)
+	enc := json.NewEncoder(buf)
+	for _, r := range records {
+		if err := enc.Encode(r); err != nil {
+			log.Error().Err(err).Str("Ticker", r.Ticker).Msg("could not encode record")
+			return err
+		}
+	}
+
+	if err := buf.Flush(); err != nil {
+		return err
+	}
+	return fh.Close()
+}
+
+// ArrowExporter writes an Arrow IPC file; numeric columns are nullable
+type ArrowExporter struct{}
+
+func (e *ArrowExporter) Format() string { return "arrow" }
+
+func (e *ArrowExporter) Export(records []*ZacksRecord, fn string) error {
+	fields := exportFields()
+	schema, err := arrowSchema(fields)
+	if err != nil {
+		return err
+	}
+
+	builder := array.NewRecordBuilder(memory.DefaultAllocator, schema)
+	defer builder.Release()
+
+	for _, r := range records {
+		value := reflect.ValueOf(r).Elem()
+		for idx, field := range fields {
+			appendArrowValue(builder.Field(idx), value.Field(field.Index))
+		}
+	}
+
+	batch := builder.NewRecordBatch()
+	defer batch.Release()
+
+	fh, err := os.Create(fn)
+	if err != nil {
+		log.Error().Err(err).Str("FileName", fn).Msg("cannot create local file")
+		return err
+	}
+	defer fh.Close()
+
+	writer, err := ipc.NewFileWriter(fh, ipc.WithSchema(schema), ipc.WithAllocator(memory.DefaultAllocator))
+	if err != nil {
+		return err
+	}
+	if err := writer.Write(batch); err != nil {
+		return err
+	}
+	if err := writer.Close(); err != nil {
+		return err
+	}
+	return fh.Close()
+}
+
+func arrowSchema(fields []*exportField) (*arrow.Schema, error) {
+	arrowFields := make([]arrow.Field, len(fields))
+	for idx, field := range fields {
+		fieldType := field.Type
+		nullable := fieldType.Kind() == reflect.Ptr
+		if nullable {
+			fieldType = fieldType.Elem()
+		}
+
+		var dataType arrow.DataType
+		switch fieldType.Kind() {
+		case reflect.String:
+			dataType = arrow.BinaryTypes.String
+		case reflect.Bool:
+			dataType = arrow.FixedWidthTypes.Boolean
+		case reflect.Float32:
+			dataType = arrow.PrimitiveTypes.Float32
+		case reflect.Float64:
+			dataType = arrow.PrimitiveTypes.Float64
+		case reflect.Int, reflect.Int32:
+			dataType = arrow.PrimitiveTypes.Int32
+		case reflect.Int64:
+			dataType = arrow.PrimitiveTypes.Int64
+		default:
+			return nil, fmt.Errorf("field %s: unsupported type %s for arrow export", field.Name, field.Type)
+		}
+
+		arrowFields[idx] = arrow.Field{Name: field.Name, Type: dataType, Nullable: nullable}
+	}
+	return arrow.NewSchema(arrowFields, nil), nil
+}
+
+func appendArrowValue(builder array.Builder, value reflect.Value) {
+	if value.Kind() == reflect.Ptr {
+		if value.IsNil() {
+			builder.AppendNull()
+			return
+		}
+		value = value.Elem()
+	}
+
+	switch b := builder.(type) {
+	case *array.StringBuilder:
+		b.Append(value.String())
+	case *array.BooleanBuilder:
+		b.Append(value.Bool())
+	case *array.Float32Builder:
+		b.Append(float32(value.Float()))
+	case *array.Float64Builder:
+		b.Append(value.Float())
+	case *array.Int32Builder:
+		b.Append(int32(value.Int()))
+	case *array.Int64Builder:
+		b.Append(value.Int())
+	}
+}
+
+// ParseExportSpec splits a --export value of the form format=path
+func ParseExportSpec(spec string) (Exporter, string, error) {
+	format, fn, ok := strings.Cut(spec, "=")
+	if !ok || format == "" || fn == "" {
+		return nil, "", fmt.Errorf("invalid export %q; expected format=path", spec)
+	}
+
+	exporter, err := NewExporter(format)
+	if err != nil {
+		return nil, "", err
+	}
+	return exporter, fn, nil
+}
+
+// ExportSink writes the batch with Exporter to Path. {date} in Path is
+// replaced with the event date (YYYY-MM-DD) of the batch and {screen} with the
+// name of its screen.
+type ExportSink struct {
+	Exporter Exporter
+	Path     string
+}
+
+func (s *ExportSink) Name() string { return "export-" + s.Exporter.Format() }
+
+func (s *ExportSink) Save(ctx context.Context, batch *Batch) error {
+	fn := strings.NewReplacer("{date}", batch.DateStr, "{screen}", batch.screen().Name).Replace(s.Path)
+	if dir := filepath.Dir(fn); dir != "" {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			log.Error().Err(err).Str("Dir", dir).Msg("cannot create export directory")
+			return err
+		}
+	}
+
+	if err := s.Exporter.Export(batch.Records, fn); err != nil {
+		log.Error().Err(err).Str("Format", s.Exporter.Format()).Str("FileName", fn).Msg("export failed")
+		return err
+	}
+
+	log.Info().Str("Format", s.Exporter.Format()).Str("FileName", fn).Int("NumRecords", len(batch.Records)).Msg("exported records")
+	return nil
+}
@@ -0,0 +1,172 @@
+/*
+Copyright 2022
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package zacks
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/apache/arrow-go/v18/arrow/ipc"
+	"github.com/apache/arrow-go/v18/arrow/memory"
+)
+
+func TestParseExportSpec(t *testing.T) {
+	tests := []struct {
+		spec   string
+		format string
+		fn     string
+		err    error
+	}{
+		{"csv=out/{date}.csv", "csv", "out/{date}.csv", nil},
+		{"ndjson=ratings.jsonl", "jsonl", "ratings.jsonl", nil},
+		{"IPC=ratings.arrow", "arrow", "ratings.arrow", nil},
+		{"parquet=a=b.parquet", "parquet", "a=b.parquet", nil},
+		{"xlsx=ratings.xlsx", "", "", ErrUnknownExportFormat},
+		{"ratings.csv", "", "", nil},
+		{"csv=", "", "", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			exporter, fn, err := ParseExportSpec(tt.spec)
+			if tt.format == "" {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				if tt.err != nil && !errors.Is(err, tt.err) {
+					t.Fatalf("expected %v, got %v", tt.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if exporter.Format() != tt.format || fn != tt.fn {
+				t.Errorf("expected %s=%s, got %s=%s", tt.format, tt.fn, exporter.Format(), fn)
+			}
+		})
+	}
+}
+
+func TestExportSink(t *testing.T) {
+	records, err := LoadRatings(sampleScreen(t), "2024-05-03", RatingsOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// check reads fn back and returns the number of rows and whether EXMP's zacks rank is null
+	tests := []struct {
+		format string
+		check  func(t *testing.T, fn string) (int, bool)
+	}{
+		{"csv", func(t *testing.T, fn string) (int, bool) {
+			fh, err := os.Open(fn)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer fh.Close()
+			rows, err := csv.NewReader(fh).ReadAll()
+			if err != nil {
+				t.Fatal(err)
+			}
+			col := -1
+			for idx, name := range rows[0] {
+				if name == "zacks_rank" {
+					col = idx
+				}
+			}
+			if col < 0 {
+				t.Fatal("csv has no zacks_rank column")
+			}
+			return len(rows) - 1, rows[3][col] == ""
+		}},
+		{"jsonl", func(t *testing.T, fn string) (int, bool) {
+			fh, err := os.Open(fn)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer fh.Close()
+			var lines []map[string]any
+			scanner := bufio.NewScanner(fh)
+			scanner.Buffer(nil, 1<<20)
+			for scanner.Scan() {
+				var line map[string]any
+				if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+					t.Fatal(err)
+				}
+				lines = append(lines, line)
+			}
+			return len(lines), lines[2]["zacks_rank"] == nil
+		}},
+		{"arrow", func(t *testing.T, fn string) (int, bool) {
+			fh, err := os.Open(fn)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer fh.Close()
+			reader, err := ipc.NewFileReader(fh, ipc.WithAllocator(memory.DefaultAllocator))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer reader.Close()
+			batch, err := reader.Record(0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			indices := batch.Schema().FieldIndices("zacks_rank")
+			if len(indices) != 1 {
+				t.Fatal("arrow file has no zacks_rank column")
+			}
+			return int(batch.NumRows()), batch.Column(indices[0]).IsNull(2)
+		}},
+		{"parquet", func(t *testing.T, fn string) (int, bool) {
+			loaded, err := LoadFromParquet(fn)
+			if err != nil {
+				t.Fatal(err)
+			}
+			return len(loaded), loaded[2].ZacksRank == nil
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			exporter, err := NewExporter(tt.format)
+			if err != nil {
+				t.Fatal(err)
+			}
+			dir := t.TempDir()
+			sink := &ExportSink{Exporter: exporter, Path: filepath.Join(dir, "{screen}", "{date}."+tt.format)}
+			if err := sink.Save(context.Background(), &Batch{DateStr: "2024-05-03", Records: records}); err != nil {
+				t.Fatal(err)
+			}
+
+			rows, null := tt.check(t, filepath.Join(dir, "ratings", "2024-05-03."+tt.format))
+			if rows != len(records) {
+				t.Errorf("expected %d rows, got %d", len(records), rows)
+			}
+			if !null {
+				t.Error("expected the NA zacks rank of EXMP to be exported as null")
+			}
+		})
+	}
+}
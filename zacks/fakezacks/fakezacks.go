@@ -0,0 +1,614 @@
+/*
+Copyright 2022
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package fakezacks serves a minimal stand-in for the parts of zacks.com that
+// Download, EnsureLoggedIn, BalanceSheet and the screen editor drive with
+// playwright, so the whole flow can run offline
+package fakezacks
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/spf13/viper"
+)
+
+//go:embed screen.csv
+var sampleScreen []byte
+
+const sessionCookie = "fakezacks_session"
+
+// DefaultScreenID is the saved screen whose run button zacks.Download clicks
+const DefaultScreenID = 137005
+
+// Criterion is one filter of a saved screen
+type Criterion struct {
+	Field    string `json:"field"`
+	Operator string `json:"operator"`
+	Value    string `json:"value"`
+}
+
+// Operators offered by the criteria editor
+var Operators = []string{">=", "<=", "=", ">", "<"}
+
+// SavedScreen is a screen listed on the My Screens tab
+type SavedScreen struct {
+	ID       int          `json:"id"`
+	Title    string       `json:"title"`
+	Criteria []*Criterion `json:"criteria"`
+	Columns  []string     `json:"columns"`
+	// Data is the CSV served by the download button after the screen is run
+	Data     []byte `json:"-"`
+	Filename string `json:"-"`
+}
+
+// BalanceSheetTable is one period of a quote balance-sheet page. Values are in
+// millions as displayed by zacks, e.g. "1,234.5" or "NA".
+type BalanceSheetTable struct {
+	Dates                   []string
+	TotalCurrentAssets      []string
+	TotalCurrentLiabilities []string
+}
+
+// BalanceSheet holds the annual and quarterly tables served for a ticker
+type BalanceSheet struct {
+	Annual    *BalanceSheetTable
+	Quarterly *BalanceSheetTable
+}
+
+// Server is a running fake zacks.com
+type Server struct {
+	*httptest.Server
+
+	Username string
+	Password string
+	Screens  []*SavedScreen
+	// Fields are the items offered as criteria fields and output columns;
+	// they default to the columns of the sample screen
+	Fields []string
+
+	// Outage makes every page answer 503 Service Unavailable
+	Outage bool
+	// FailRequests makes that many requests answer 503 before the site
+	// recovers, e.g. to exercise retries
+	FailRequests int
+	// Challenge replaces every page with a captcha
+	Challenge bool
+	// SubscriptionExpired lets the login succeed but shows an expired
+	// subscription notice instead of the logged in marker
+	SubscriptionExpired bool
+	// BalanceSheets is keyed by the zacks ticker (BRK.B rather than BRK/B);
+	// other tickers get a page without balance sheet tables
+	BalanceSheets map[string]*BalanceSheet
+
+	mu        sync.Mutex
+	logins    int
+	downloads int
+	requests  map[string]int
+}
+
+// New starts a fake zacks.com that accepts username/password and serves a
+// small sample screen and a balance sheet for AAPL. Fields may be changed
+// before the first request. Call Close when done.
+func New(username, password string) *Server {
+	fields := sampleColumns()
+	s := &Server{
+		Username: username,
+		Password: password,
+		Screens: []*SavedScreen{
+			{
+				ID:       DefaultScreenID,
+				Title:    "Zacks Rank Import",
+				Criteria: []*Criterion{{Field: "Zacks Rank", Operator: "<=", Value: "5"}},
+				Columns:  append([]string(nil), fields...),
+				Data:     sampleScreen,
+				Filename: "zacks_custom_screen_2024-05-03.csv",
+			},
+		},
+		Fields: fields,
+		BalanceSheets: map[string]*BalanceSheet{
+			"AAPL": {
+				Annual: &BalanceSheetTable{
+					Dates:                   []string{"9/30/2023", "9/30/2022"},
+					TotalCurrentAssets:      []string{"143,566", "135,405"},
+					TotalCurrentLiabilities: []string{"145,308", "153,982"},
+				},
+				Quarterly: &BalanceSheetTable{
+					Dates:                   []string{"3/31/2024", "12/31/2023"},
+					TotalCurrentAssets:      []string{"128,416", "143,692"},
+					TotalCurrentLiabilities: []string{"123,822", "133,973"},
+				},
+			},
+		},
+		requests: make(map[string]int),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", s.homepage)
+	mux.HandleFunc("/logout.php", s.loginForm)
+	mux.HandleFunc("/login", s.login)
+	mux.HandleFunc("/screening/stock-screener", s.screener)
+	mux.HandleFunc("/screening/screener-content", s.screenerContent)
+	mux.HandleFunc("/screening/export", s.export)
+	mux.HandleFunc("/screening/screen", s.savedScreen)
+	mux.HandleFunc("/screening/save", s.saveScreen)
+	mux.HandleFunc("/stock/quote/", s.balanceSheet)
+
+	s.Server = httptest.NewServer(s.count(mux))
+	return s
+}
+
+func sampleColumns() []string {
+	header, err := csv.NewReader(bytes.NewReader(sampleScreen)).Read()
+	if err != nil {
+		panic(fmt.Sprintf("fakezacks: invalid sample screen: %v", err))
+	}
+	return header
+}
+
+// Configure points the zacks.urls.* config keys at the fake server and sets
+// the zacks credentials
+func (s *Server) Configure() {
+	viper.Set("zacks.urls.homepage", s.URL+"/")
+	viper.Set("zacks.urls.login", s.URL+"/logout.php")
+	viper.Set("zacks.urls.stock_screener", s.URL+"/screening/stock-screener")
+	viper.Set("zacks.urls.balance_sheet", s.URL+"/stock/quote/%s/balance-sheet")
+	viper.Set("zacks.username", s.Username)
+	viper.Set("zacks.password", s.Password)
+}
+
+// AddScreen adds a saved screen that serves data when run
+func (s *Server) AddScreen(id int, title string, data []byte, filename string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Screens = append(s.Screens, &SavedScreen{ID: id, Title: title, Data: data, Filename: filename})
+}
+
+func (s *Server) screen(id int) *SavedScreen {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, screen := range s.Screens {
+		if screen.ID == id {
+			return screen
+		}
+	}
+	return nil
+}
+
+// Logins returns the number of successful logins
+func (s *Server) Logins() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.logins
+}
+
+// Downloads returns the number of times a screen CSV was downloaded
+func (s *Server) Downloads() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.downloads
+}
+
+// Requests returns the number of requests made for path
+func (s *Server) Requests(path string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests[path]
+}
+
+func (s *Server) count(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		s.requests[r.URL.Path]++
+		outage, challenge := s.Outage || s.FailRequests > 0, s.Challenge
+		if s.FailRequests > 0 {
+			s.FailRequests--
+		}
+		s.mu.Unlock()
+
+		switch {
+		case outage:
+			http.Error(w, "Service Unavailable", http.StatusServiceUnavailable)
+		case challenge:
+			render(w, challengeTmpl, nil)
+		default:
+			next.ServeHTTP(w, r)
+		}
+	})
+}
+
+var challengeTmpl = template.Must(template.New("challenge").Parse(`<!DOCTYPE html>
+<html><head><title>Access to this page has been denied</title></head>
+<body>
+<p>Please verify you are a human</p>
+<div id="px-captcha"></div>
+</body></html>`))
+
+func (s *Server) loggedIn(r *http.Request) bool {
+	cookie, err := r.Cookie(sessionCookie)
+	return err == nil && cookie.Value == s.Username
+}
+
+func render(w http.ResponseWriter, tmpl *template.Template, data interface{}) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := tmpl.Execute(w, data); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+var homepageTmpl = template.Must(template.New("home").Parse(`<!DOCTYPE html>
+<html><head><title>Zacks Investment Research</title></head>
+<body>
+{{if .Expired}}
+<ul id="user_menu"><li><a href="/logout.php">Sign Out</a></li></ul>
+<p class="notice">Your subscription has expired. Renew now to regain access.</p>
+{{else if .LoggedIn}}
+<ul id="user_menu"><li class="welcome_usn">Welcome, {{.Username}}</li></ul>
+{{else}}
+<ul id="user_menu"><li><a href="/logout.php">Sign In</a></li></ul>
+{{end}}
+<div class="footer"><a href="/faq">What happens when my subscription has expired?</a> <a href="/faq">I forgot my password or entered an invalid password</a></div>
+</body></html>`))
+
+func (s *Server) homepage(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/" {
+		http.NotFound(w, r)
+		return
+	}
+	s.mu.Lock()
+	expired := s.SubscriptionExpired
+	s.mu.Unlock()
+
+	loggedIn := s.loggedIn(r)
+	render(w, homepageTmpl, map[string]interface{}{
+		"LoggedIn": loggedIn,
+		"Expired":  loggedIn && expired,
+		"Username": s.Username,
+	})
+}
+
+var loginTmpl = template.Must(template.New("login").Parse(`<!DOCTYPE html>
+<html><head><title>Sign In</title></head>
+<body>
+<div id="login">
+{{if .Failed}}<p class="error">Invalid username or password</p>{{end}}
+<form method="post" action="/login">
+<input type="text" name="username">
+<input type="password" name="password">
+<input type="submit" value="Login">
+</form>
+</div>
+<div class="footer"><a href="/faq">What happens when my subscription has expired?</a> <a href="/faq">I forgot my password or entered an invalid password</a></div>
+</body></html>`))
+
+func (s *Server) loginForm(w http.ResponseWriter, r *http.Request) {
+	// like zacks.com, visiting the login page ends the current session
+	http.SetCookie(w, &http.Cookie{Name: sessionCookie, Path: "/", MaxAge: -1})
+	render(w, loginTmpl, map[string]interface{}{"Failed": r.URL.Query().Get("failed") != ""})
+}
+
+func (s *Server) login(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if r.PostFormValue("username") != s.Username || r.PostFormValue("password") != s.Password {
+		http.Redirect(w, r, "/logout.php?failed=1", http.StatusSeeOther)
+		return
+	}
+
+	s.mu.Lock()
+	s.logins++
+	s.mu.Unlock()
+
+	http.SetCookie(w, &http.Cookie{Name: sessionCookie, Value: s.Username, Path: "/"})
+	http.Redirect(w, r, "/", http.StatusSeeOther)
+}
+
+var screenerTmpl = template.Must(template.New("screener").Parse(`<!DOCTYPE html>
+<html><head><title>Stock Screener</title></head>
+<body>
+<iframe id="screenerContent" src="/screening/screener-content" width="1200" height="800"></iframe>
+</body></html>`))
+
+func (s *Server) screener(w http.ResponseWriter, r *http.Request) {
+	if !s.loggedIn(r) {
+		http.Redirect(w, r, "/logout.php", http.StatusSeeOther)
+		return
+	}
+	render(w, screenerTmpl, nil)
+}
+
+var screenerContentTmpl = template.Must(template.New("content").Parse(`<!DOCTYPE html>
+<html><head><title>Screener</title></head>
+<body>
+<ul>
+<li><a id="screen-criteria-tab" href="#">Screen Criteria</a></li>
+<li><a id="my-screen-tab" href="#" onclick="document.getElementById('my_screens').style.display='block'; return false;">My Screens</a></li>
+</ul>
+<div id="my_screens" style="display:none">
+<button id="btn_new_screen" onclick="newScreen()">New Screen</button>
+<table>{{range .Screens}}
+<tr><td>{{.Title}}</td><td><button id="btn_run_{{.ID}}" onclick="runScreen({{.ID}})">Run</button></td><td><button id="btn_edit_{{.ID}}" onclick="editScreen({{.ID}})">Edit</button></td></tr>{{end}}
+</table>
+</div>
+<div id="screen_editor" style="display:none" data-screen-id="">
+<input type="text" id="screen_name">
+<table id="criteria_table"><tbody></tbody></table>
+<select id="criteria_field">{{range .Fields}}<option>{{.}}</option>{{end}}</select>
+<select id="criteria_operator">{{range .Operators}}<option>{{.}}</option>{{end}}</select>
+<input type="text" id="criteria_value">
+<button id="btn_add_criteria" onclick="addCriteria()">Add</button>
+<ul id="output_columns"></ul>
+<select id="column_select">{{range .Fields}}<option>{{.}}</option>{{end}}</select>
+<button id="btn_add_column" onclick="addColumn()">Add Column</button>
+<button id="btn_save_screen" onclick="saveScreen()">Save</button>
+<span id="save_status"></span>
+</div>
+<div id="screener_table_wrapper"></div>
+<script>
+var current = null;
+
+function el(id) { return document.getElementById(id); }
+
+function removeLink(className, list, idx) {
+  var a = document.createElement('a');
+  a.href = '#';
+  a.className = className;
+  a.textContent = 'remove';
+  a.onclick = function() { list.splice(idx, 1); render(); return false; };
+  return a;
+}
+
+function render() {
+  var editor = el('screen_editor');
+  editor.setAttribute('data-screen-id', current.id || '');
+  editor.style.display = 'block';
+  el('save_status').textContent = '';
+
+  var body = el('criteria_table').tBodies[0];
+  body.innerHTML = '';
+  current.criteria.forEach(function(c, idx) {
+    var tr = document.createElement('tr');
+    [['criteria_field', c.field], ['criteria_operator', c.operator], ['criteria_value', c.value]].forEach(function(cell) {
+      var td = document.createElement('td');
+      td.className = cell[0];
+      td.textContent = cell[1];
+      tr.appendChild(td);
+    });
+    var td = document.createElement('td');
+    td.appendChild(removeLink('remove_criteria', current.criteria, idx));
+    tr.appendChild(td);
+    body.appendChild(tr);
+  });
+
+  var ul = el('output_columns');
+  ul.innerHTML = '';
+  current.columns.forEach(function(col, idx) {
+    var li = document.createElement('li');
+    li.setAttribute('data-column', col);
+    li.appendChild(document.createTextNode(col + ' '));
+    li.appendChild(removeLink('remove_column', current.columns, idx));
+    ul.appendChild(li);
+  });
+}
+
+function show(screen) {
+  current = screen;
+  current.criteria = current.criteria || [];
+  current.columns = current.columns || [];
+  el('screen_name').value = current.title;
+  render();
+}
+
+function newScreen() {
+  show({id: 0, title: '', criteria: [], columns: []});
+}
+
+function editScreen(id) {
+  fetch('/screening/screen?id=' + id).then(function(r) { return r.json(); }).then(show);
+}
+
+function addCriteria() {
+  current.criteria.push({field: el('criteria_field').value, operator: el('criteria_operator').value, value: el('criteria_value').value});
+  render();
+}
+
+function addColumn() {
+  var col = el('column_select').value;
+  if (current.columns.indexOf(col) < 0) {
+    current.columns.push(col);
+  }
+  render();
+}
+
+function saveScreen() {
+  current.title = el('screen_name').value;
+  fetch('/screening/save', {method: 'POST', body: JSON.stringify(current)}).then(function(r) { return r.json(); }).then(function(saved) {
+    current.id = saved.id;
+    el('screen_editor').setAttribute('data-screen-id', saved.id);
+    el('save_status').textContent = 'Saved';
+  });
+}
+
+function runScreen(id) {
+  setTimeout(function() {
+    document.getElementById('screener_table_wrapper').innerHTML =
+      '<div class="dt-buttons"><a class="dt-button buttons-csv buttons-html5" href="/screening/export?id=' + id + '">CSV</a></div>' +
+      '<table id="screener_table"><tr><td>results</td></tr></table>';
+  }, 200);
+}
+</script>
+</body></html>`))
+
+func (s *Server) screenerContent(w http.ResponseWriter, r *http.Request) {
+	if !s.loggedIn(r) {
+		http.Error(w, "not logged in", http.StatusForbidden)
+		return
+	}
+	s.mu.Lock()
+	screens := append([]*SavedScreen(nil), s.Screens...)
+	s.mu.Unlock()
+	render(w, screenerContentTmpl, map[string]interface{}{
+		"Screens":   screens,
+		"Fields":    s.Fields,
+		"Operators": Operators,
+	})
+}
+
+func (s *Server) savedScreen(w http.ResponseWriter, r *http.Request) {
+	if !s.loggedIn(r) {
+		http.Error(w, "not logged in", http.StatusForbidden)
+		return
+	}
+
+	id, _ := strconv.Atoi(r.URL.Query().Get("id"))
+	screen := s.screen(id)
+	if screen == nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(screen)
+}
+
+// saveScreen stores the criteria, columns and title posted by the editor,
+// creating a new saved screen when the id is 0
+func (s *Server) saveScreen(w http.ResponseWriter, r *http.Request) {
+	if !s.loggedIn(r) {
+		http.Error(w, "not logged in", http.StatusForbidden)
+		return
+	}
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	posted := &SavedScreen{}
+	if err := json.NewDecoder(r.Body).Decode(posted); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	s.mu.Lock()
+	var screen *SavedScreen
+	nextID := DefaultScreenID
+	for _, candidate := range s.Screens {
+		if candidate.ID == posted.ID {
+			screen = candidate
+		}
+		if candidate.ID >= nextID {
+			nextID = candidate.ID + 1
+		}
+	}
+	if screen == nil || posted.ID == 0 {
+		screen = &SavedScreen{ID: nextID}
+		s.Screens = append(s.Screens, screen)
+	}
+	screen.Title = posted.Title
+	screen.Criteria = posted.Criteria
+	screen.Columns = posted.Columns
+	id := screen.ID
+	s.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]int{"id": id})
+}
+
+func (s *Server) export(w http.ResponseWriter, r *http.Request) {
+	if !s.loggedIn(r) {
+		http.Error(w, "not logged in", http.StatusForbidden)
+		return
+	}
+
+	id, _ := strconv.Atoi(r.URL.Query().Get("id"))
+	screen := s.screen(id)
+	if screen == nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	s.mu.Lock()
+	s.downloads++
+	s.mu.Unlock()
+
+	w.Header().Set("Content-Type", "text/csv")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, screen.Filename))
+	w.Write(screen.Data)
+}
+
+// balance sheet tables are laid out so that their text content splits into
+// one line per cell, which is what parseHeader and parseRow rely on
+var balanceSheetTmpl = template.Must(template.New("balance").Parse(`<!DOCTYPE html>
+<html><head><title>{{.Ticker}} Balance Sheet</title></head>
+<body>
+<ul role="tablist">
+<li><a href="#annual" onclick="show('annual'); return false;">Annual Balance Sheet</a></li>
+<li><a href="#quarterly" onclick="show('quarterly'); return false;">Quarterly Balance Sheet</a></li>
+</ul>
+{{with .Sheet}}
+{{with .Annual}}<div id="annual_income_statement">{{template "table" .}}</div>{{end}}
+{{with .Quarterly}}<div id="quarterly_income_statement" style="display:none">{{template "table" .}}</div>{{end}}
+{{end}}
+<script>
+function show(period) {
+  var annual = document.getElementById('annual_income_statement');
+  var quarterly = document.getElementById('quarterly_income_statement');
+  if (annual) { annual.style.display = period === 'annual' ? 'block' : 'none'; }
+  if (quarterly) { quarterly.style.display = period === 'quarterly' ? 'block' : 'none'; }
+}
+</script>
+</body></html>
+{{define "table"}}<table>
+<thead><tr><th></th>{{range .Dates}}
+<th>{{.}}</th>{{end}}</tr></thead>
+<tbody>
+<tr>
+<td>Total Current Assets</td>{{range .TotalCurrentAssets}}
+<td>{{.}}</td>{{end}}</tr>
+<tr>
+<td>Total Current Liabilities</td>{{range .TotalCurrentLiabilities}}
+<td>{{.}}</td>{{end}}</tr>
+</tbody>
+</table>{{end}}`))
+
+func (s *Server) balanceSheet(w http.ResponseWriter, r *http.Request) {
+	// /stock/quote/{ticker}/balance-sheet
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if len(parts) != 4 || parts[3] != "balance-sheet" {
+		http.NotFound(w, r)
+		return
+	}
+
+	ticker := parts[2]
+	render(w, balanceSheetTmpl, map[string]interface{}{
+		"Ticker": ticker,
+		"Sheet":  s.BalanceSheets[ticker],
+	})
+}
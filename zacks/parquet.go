@@ -2,8 +2,11 @@ package zacks
 
 import (
 	"github.com/rs/zerolog/log"
+	"github.com/xitongsys/parquet-go-source/buffer"
 	"github.com/xitongsys/parquet-go-source/local"
 	"github.com/xitongsys/parquet-go/parquet"
+	"github.com/xitongsys/parquet-go/reader"
+	"github.com/xitongsys/parquet-go/source"
 	"github.com/xitongsys/parquet-go/writer"
 )
 
@@ -48,6 +51,70 @@ func SaveToParquet(records []*ZacksRecord, fn string) error {
 	return nil
 }
 
+// LoadFromParquet reads records previously written by SaveToParquet, by this
+// or any earlier version
+func LoadFromParquet(fn string) ([]*ZacksRecord, error) {
+	fh, err := local.NewLocalFileReader(fn)
+	if err != nil {
+		log.Error().Err(err).Str("FileName", fn).Msg("cannot open local file")
+		return nil, err
+	}
+	defer fh.Close()
+
+	return readParquet(fh, fn)
+}
+
+// ParseParquet reads records from the contents of a parquet file written by SaveToParquet
+func ParseParquet(data []byte, name string) ([]*ZacksRecord, error) {
+	return readParquet(buffer.NewBufferFileFromBytesNoAlloc(data), name)
+}
+
+func readParquet(fh source.ParquetFile, name string) ([]*ZacksRecord, error) {
+	version, err := parquetSchemaVersion(fh)
+	if err != nil {
+		log.Error().Err(err).Str("FileName", name).Msg("Parquet read failed")
+		return nil, err
+	}
+
+	// reading a version 1 file with the current, nullable schema succeeds but
+	// leaves every numeric field nil
+	if version == 1 {
+		records, err := readLegacyParquet(fh)
+		if err != nil {
+			log.Error().Err(err).Str("FileName", name).Int("SchemaVersion", version).Msg("Parquet read failed")
+			return nil, err
+		}
+		for _, r := range records {
+			r.syncDates()
+		}
+		log.Info().Int("NumRecords", len(records)).Str("FileName", name).Int("SchemaVersion", version).Msg("Parquet read finished; converted from an older schema")
+		return records, nil
+	}
+
+	pr, err := reader.NewParquetReader(fh, new(ZacksRecord), 4)
+	if err != nil {
+		log.Error().Err(err).Str("FileName", name).Msg("Parquet read failed")
+		return nil, err
+	}
+	defer pr.ReadStop()
+
+	num := int(pr.GetNumRows())
+	rows := make([]ZacksRecord, num)
+	if err = pr.Read(&rows); err != nil {
+		log.Error().Err(err).Str("FileName", name).Msg("Parquet read failed")
+		return nil, err
+	}
+
+	records := make([]*ZacksRecord, num)
+	for idx := range rows {
+		rows[idx].syncDates()
+		records[idx] = &rows[idx]
+	}
+
+	log.Info().Int("NumRecords", num).Str("FileName", name).Msg("Parquet read finished")
+	return records, nil
+}
+
 func (balanceSheetList BalanceSheetList) SaveToParquet(fn string) error {
 	var err error
 
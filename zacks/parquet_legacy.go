@@ -0,0 +1,113 @@
+/*
+Copyright 2022
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package zacks
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/xitongsys/parquet-go/parquet"
+	"github.com/xitongsys/parquet-go/reader"
+	"github.com/xitongsys/parquet-go/source"
+)
+
+// schemaProbeColumn is a numeric column whose repetition type tells the
+// ratings schema versions apart: REQUIRED in version 1, OPTIONAL since 2
+const schemaProbeColumn = "zacks_rank"
+
+// parquetSchemaVersion returns the ratings schema version fh was written with
+func parquetSchemaVersion(fh source.ParquetFile) (int, error) {
+	pr := &reader.ParquetReader{PFile: fh}
+	if err := pr.ReadFooter(); err != nil {
+		return 0, err
+	}
+
+	for _, elem := range pr.Footer.Schema {
+		if !strings.EqualFold(elem.Name, schemaProbeColumn) {
+			continue
+		}
+		if elem.GetRepetitionType() == parquet.FieldRepetitionType_OPTIONAL {
+			return RatingsSchemaVersion, nil
+		}
+		return 1, nil
+	}
+
+	return 0, fmt.Errorf("%w: no %s column", ErrUnknownSchemaVersion, schemaProbeColumn)
+}
+
+// legacyRecordType is ZacksRecord as written by schema version 1, where the
+// numeric columns were REQUIRED and missing values were stored as zero. It is
+// derived from ZacksRecord so the two cannot drift apart.
+var legacyRecordType = func() reflect.Type {
+	recordType := reflect.TypeOf(ZacksRecord{})
+	fields := make([]reflect.StructField, recordType.NumField())
+	for ii := range fields {
+		field := recordType.Field(ii)
+		tag := field.Tag.Get("parquet")
+		if field.Type.Kind() == reflect.Ptr {
+			field.Type = field.Type.Elem()
+			tag = strings.ReplaceAll(tag, ", repetitiontype=OPTIONAL", "")
+		}
+		field.Tag = ""
+		if tag != "" {
+			field.Tag = reflect.StructTag(fmt.Sprintf("parquet:%q", tag))
+		}
+		fields[ii] = field
+	}
+	return reflect.StructOf(fields)
+}()
+
+// readLegacyParquet reads a schema version 1 file and converts its rows to
+// ZacksRecord. Numeric values are kept as stored, so the zeros version 1 wrote
+// for missing values stay zero rather than becoming null.
+func readLegacyParquet(fh source.ParquetFile) ([]*ZacksRecord, error) {
+	pr, err := reader.NewParquetReader(fh, reflect.New(legacyRecordType).Interface(), 4)
+	if err != nil {
+		return nil, err
+	}
+	defer pr.ReadStop()
+
+	num := int(pr.GetNumRows())
+	rows := reflect.New(reflect.SliceOf(legacyRecordType))
+	rows.Elem().Set(reflect.MakeSlice(rows.Elem().Type(), num, num))
+	if err := pr.Read(rows.Interface()); err != nil {
+		return nil, err
+	}
+
+	records := make([]*ZacksRecord, num)
+	for idx := range records {
+		records[idx] = upgradeLegacyRecord(rows.Elem().Index(idx))
+	}
+	return records, nil
+}
+
+func upgradeLegacyRecord(legacy reflect.Value) *ZacksRecord {
+	record := &ZacksRecord{}
+	value := reflect.ValueOf(record).Elem()
+	for ii := 0; ii < value.NumField(); ii++ {
+		field := value.Field(ii)
+		if field.Kind() == reflect.Ptr {
+			ptr := reflect.New(field.Type().Elem())
+			ptr.Elem().Set(legacy.Field(ii))
+			field.Set(ptr)
+			continue
+		}
+		field.Set(legacy.Field(ii))
+	}
+	return record
+}
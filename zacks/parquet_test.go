@@ -0,0 +1,175 @@
+/*
+Copyright 2022
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package zacks
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/penny-vault/import-zacks-rank/storage"
+	"github.com/xitongsys/parquet-go-source/buffer"
+)
+
+// v1Fixture was written by SaveToParquet before the numeric columns became
+// OPTIONAL (schema version 1)
+const v1Fixture = "testdata/zacks-v1-20210604.parquet"
+
+// captureSink keeps the last batch it was given
+type captureSink struct {
+	batch *Batch
+}
+
+func (s *captureSink) Name() string { return "capture" }
+
+func (s *captureSink) Save(ctx context.Context, batch *Batch) error {
+	s.batch = batch
+	return nil
+}
+
+func newTestArchiver(t *testing.T) *storage.Archiver {
+	t.Helper()
+	store, err := storage.NewLocalStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &storage.Archiver{Store: store, Layout: &storage.LegacyLayout{}}
+}
+
+func TestParquetSchemaVersion(t *testing.T) {
+	fn := filepath.Join(t.TempDir(), "zacks-20240503.parquet")
+	if err := SaveToParquet([]*ZacksRecord{{Ticker: "AAPL", EventDateStr: "2024-05-03"}}, fn); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		fn   string
+		want int
+	}{
+		{v1Fixture, 1},
+		{fn, RatingsSchemaVersion},
+	}
+
+	for _, tt := range tests {
+		data, err := os.ReadFile(tt.fn)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := parquetSchemaVersion(buffer.NewBufferFileFromBytesNoAlloc(data))
+		if err != nil {
+			t.Fatalf("%s: %v", tt.fn, err)
+		}
+		if got != tt.want {
+			t.Errorf("%s: schema version %d, want %d", tt.fn, got, tt.want)
+		}
+	}
+}
+
+func TestLoadFromParquetV1(t *testing.T) {
+	records, err := LoadFromParquet(v1Fixture)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 2 {
+		t.Fatalf("read %d records, want 2", len(records))
+	}
+
+	aapl := records[0]
+	if aapl.Ticker != "AAPL" || aapl.ValueScore != "C" {
+		t.Errorf("text fields not read: %+v", aapl)
+	}
+	if aapl.LastClose == nil || *aapl.LastClose != 125.89 {
+		t.Errorf("LastClose = %v, want 125.89", aapl.LastClose)
+	}
+	if aapl.ZacksRank == nil || *aapl.ZacksRank != 3 {
+		t.Errorf("ZacksRank = %v, want 3", aapl.ZacksRank)
+	}
+	// zero was how version 1 stored missing values; it is kept as stored
+	if aapl.EarningsEsp == nil || *aapl.EarningsEsp != 0 {
+		t.Errorf("EarningsEsp = %v, want 0", aapl.EarningsEsp)
+	}
+	if want := time.Date(2021, 6, 4, 0, 0, 0, 0, time.UTC); !aapl.EventDate.Equal(want) {
+		t.Errorf("EventDate = %s, want %s", aapl.EventDate, want)
+	}
+}
+
+func TestLoadFromParquetKeepsNulls(t *testing.T) {
+	rank := 1
+	fn := filepath.Join(t.TempDir(), "zacks-20240503.parquet")
+	if err := SaveToParquet([]*ZacksRecord{{Ticker: "AAPL", EventDateStr: "2024-05-03", ZacksRank: &rank}}, fn); err != nil {
+		t.Fatal(err)
+	}
+
+	records, err := LoadFromParquet(fn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 1 {
+		t.Fatalf("read %d records, want 1", len(records))
+	}
+	if records[0].ZacksRank == nil || *records[0].ZacksRank != 1 {
+		t.Errorf("ZacksRank = %v, want 1", records[0].ZacksRank)
+	}
+	if records[0].LastClose != nil {
+		t.Errorf("LastClose = %v, want nil", *records[0].LastClose)
+	}
+}
+
+func TestRestoreV1Archive(t *testing.T) {
+	ctx := context.Background()
+	archiver := newTestArchiver(t)
+	date := time.Date(2021, 6, 4, 0, 0, 0, 0, time.UTC)
+	if _, err := archiver.Archive(ctx, storage.DatasetRatings, date, v1Fixture, 2, 1); err != nil {
+		t.Fatal(err)
+	}
+
+	sink := &captureSink{}
+	pipeline := &Pipeline{
+		Source: &ArchiveSource{Archiver: archiver, Date: date},
+		Parse:  &ParquetParseStage{},
+		Sinks:  []Sink{sink},
+	}
+	if _, err := pipeline.Run(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	if sink.batch == nil || len(sink.batch.Records) != 2 {
+		t.Fatalf("sink did not receive the two archived records")
+	}
+	for _, r := range sink.batch.Records {
+		if r.LastClose == nil || r.ZacksRank == nil || r.MarketCapMil == nil {
+			t.Errorf("%s: numeric fields were not restored: %+v", r.Ticker, r)
+		}
+	}
+}
+
+func TestRestoreRefusesNewerSchema(t *testing.T) {
+	ctx := context.Background()
+	archiver := newTestArchiver(t)
+	date := time.Date(2021, 6, 4, 0, 0, 0, 0, time.UTC)
+	if _, err := archiver.Archive(ctx, storage.DatasetRatings, date, v1Fixture, 2, RatingsSchemaVersion+1); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := (&ArchiveSource{Archiver: archiver, Date: date}).Fetch(ctx)
+	if !errors.Is(err, ErrUnknownSchemaVersion) {
+		t.Errorf("Fetch error = %v, want ErrUnknownSchemaVersion", err)
+	}
+}
@@ -0,0 +1,194 @@
+/*
+Copyright 2022
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package zacks
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/viper"
+)
+
+// Batch carries a single screener download through the import pipeline
+type Batch struct {
+	// Data is the raw CSV returned by the source
+	Data []byte
+	// Filename is the name the source associated with Data, e.g. the suggested download name
+	Filename string
+	// Path is the local file Data was read from, if any
+	Path string
+	// DateStr is the event date of the batch formatted as YYYY-MM-DD
+	DateStr string
+	// Screen is the saved screen the batch was downloaded from; nil means the default screen
+	Screen  *Screen
+	Records []*ZacksRecord
+	// RawHeader and RawRows are set instead of Records for SchemaRaw screens
+	RawHeader []string
+	RawRows   [][]string
+
+	// TmpDir is a scratch directory sinks may write to; it is removed when the pipeline finishes
+	TmpDir string
+	// ParquetFn is set by ParquetSink so later sinks can archive the file
+	ParquetFn string
+	// NumRejects is set by RulesValidator, and RejectsFn when it wrote the rejected rows to a file
+	RejectsFn  string
+	NumRejects int
+	// LoadStats is set by DatabaseSink
+	LoadStats *LoadStats
+}
+
+// NumRows returns the number of parsed rows in the batch
+func (batch *Batch) NumRows() int {
+	if batch.RawHeader != nil {
+		return len(batch.RawRows)
+	}
+	return len(batch.Records)
+}
+
+// screen returns the screen of the batch, defaulting to DefaultScreen
+func (batch *Batch) screen() *Screen {
+	if batch.Screen == nil {
+		return DefaultScreen()
+	}
+	return batch.Screen
+}
+
+// Source produces the raw screener data that feeds the pipeline
+type Source interface {
+	Name() string
+	Fetch(ctx context.Context) (*Batch, error)
+}
+
+// Stage transforms a batch in place
+type Stage interface {
+	Name() string
+	Run(ctx context.Context, batch *Batch) error
+}
+
+// Sink persists a parsed batch
+type Sink interface {
+	Name() string
+	Save(ctx context.Context, batch *Batch) error
+}
+
+// Pipeline runs a source through the parse, enrich and validate stages and hands
+// the result to each sink in order. Any stage may be nil to skip it.
+type Pipeline struct {
+	Source   Source
+	Parse    Stage
+	Enrich   Stage
+	Validate Stage
+	Sinks    []Sink
+}
+
+// NewPipeline returns a pipeline with the default parse, enrich and validate
+// stages. Without sinks nothing is saved, so rejected rows are only logged.
+func NewPipeline(source Source, sinks ...Sink) *Pipeline {
+	rejectsDir := ""
+	if len(sinks) > 0 {
+		rejectsDir = viper.GetString("validation.rejects_dir")
+	}
+
+	return &Pipeline{
+		Source: source,
+		Parse: &ParseStage{
+			Limit:    viper.GetInt("limit"),
+			Strict:   viper.GetBool("zacks.strict_columns"),
+			NAAsZero: viper.GetBool("zacks.na_as_zero"),
+			Dates:    DefaultDateResolver(viper.GetString("event_date")),
+		},
+		Enrich:   &FigiEnricher{},
+		Validate: &RulesValidator{RejectsDir: rejectsDir},
+		Sinks:    sinks,
+	}
+}
+
+// NewScreenPipeline returns a pipeline with the stages for the schema of screen
+func NewScreenPipeline(screen *Screen, source Source, sinks ...Sink) *Pipeline {
+	if screen.Schema == SchemaRaw {
+		return &Pipeline{
+			Source: source,
+			Parse:  &RawParseStage{Dates: DefaultDateResolver(viper.GetString("event_date"))},
+			Sinks:  sinks,
+		}
+	}
+
+	return NewPipeline(source, sinks...)
+}
+
+// Run executes the pipeline and returns the processed batch
+func (p *Pipeline) Run(ctx context.Context) (*Batch, error) {
+	if p.Source == nil {
+		return nil, ErrNoSource
+	}
+
+	log.Info().Str("Source", p.Source.Name()).Msg("fetching screener data")
+	batch, err := p.Source.Fetch(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("source %s: %w", p.Source.Name(), err)
+	}
+
+	if err := p.Process(ctx, batch); err != nil {
+		return batch, err
+	}
+
+	return batch, p.Deliver(ctx, batch)
+}
+
+// Process runs the parse, enrich and validate stages on a batch
+func (p *Pipeline) Process(ctx context.Context, batch *Batch) error {
+	for _, stage := range []Stage{p.Parse, p.Enrich, p.Validate} {
+		if stage == nil {
+			continue
+		}
+
+		log.Debug().Str("Stage", stage.Name()).Msg("running pipeline stage")
+		if err := stage.Run(ctx, batch); err != nil {
+			return fmt.Errorf("stage %s: %w", stage.Name(), err)
+		}
+	}
+
+	return nil
+}
+
+// Deliver hands a processed batch to each sink in order
+func (p *Pipeline) Deliver(ctx context.Context, batch *Batch) error {
+	if len(p.Sinks) == 0 {
+		return nil
+	}
+
+	var err error
+	batch.TmpDir, err = os.MkdirTemp(os.TempDir(), "import-zacks")
+	if err != nil {
+		log.Error().Err(err).Msg("could not create tempdir")
+		return err
+	}
+
+	// cleanup after ourselves
+	defer os.RemoveAll(batch.TmpDir)
+
+	for _, sink := range p.Sinks {
+		log.Debug().Str("Sink", sink.Name()).Msg("running pipeline sink")
+		if err := sink.Save(ctx, batch); err != nil {
+			return fmt.Errorf("sink %s: %w", sink.Name(), err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,172 @@
+/*
+Copyright 2022
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package zacks
+
+import (
+	"context"
+	"errors"
+	"os"
+	"reflect"
+	"testing"
+)
+
+var errFake = errors.New("fake failure")
+
+// sampleScreen returns the screener CSV served by fakezacks
+func sampleScreen(t *testing.T) []byte {
+	t.Helper()
+	data, err := os.ReadFile("fakezacks/screen.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+// fakeSource returns a copy of batch, or err
+type fakeSource struct {
+	batch Batch
+	err   error
+	calls *[]string
+}
+
+func (s *fakeSource) Name() string { return "fake" }
+
+func (s *fakeSource) Fetch(ctx context.Context) (*Batch, error) {
+	*s.calls = append(*s.calls, "source")
+	if s.err != nil {
+		return nil, s.err
+	}
+	batch := s.batch
+	return &batch, nil
+}
+
+// fakeStep is a stage and a sink that records when it runs
+type fakeStep struct {
+	name  string
+	err   error
+	calls *[]string
+}
+
+func (s *fakeStep) Name() string { return s.name }
+
+func (s *fakeStep) Run(ctx context.Context, batch *Batch) error {
+	*s.calls = append(*s.calls, s.name)
+	return s.err
+}
+
+func (s *fakeStep) Save(ctx context.Context, batch *Batch) error {
+	*s.calls = append(*s.calls, s.name)
+	if batch.TmpDir == "" {
+		return errors.New("sink called without a tmp dir")
+	}
+	if _, err := os.Stat(batch.TmpDir); err != nil {
+		return err
+	}
+	return s.err
+}
+
+func TestPipelineRun(t *testing.T) {
+	tests := []struct {
+		name      string
+		noSource  bool
+		sourceErr error
+		failStep  string
+		want      []string
+		err       error
+	}{
+		{"all steps run in order", false, nil, "", []string{"source", "parse", "enrich", "validate", "archive", "database"}, nil},
+		{"missing source", true, nil, "", []string{}, ErrNoSource},
+		{"source error stops the pipeline", false, errFake, "", []string{"source"}, errFake},
+		{"stage error skips the sinks", false, nil, "enrich", []string{"source", "parse", "enrich"}, errFake},
+		{"sink error skips later sinks", false, nil, "archive", []string{"source", "parse", "enrich", "validate", "archive"}, errFake},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := []string{}
+			step := func(name string) *fakeStep {
+				s := &fakeStep{name: name, calls: &calls}
+				if name == tt.failStep {
+					s.err = errFake
+				}
+				return s
+			}
+
+			pipeline := &Pipeline{
+				Parse:    step("parse"),
+				Enrich:   step("enrich"),
+				Validate: step("validate"),
+				Sinks:    []Sink{step("archive"), step("database")},
+			}
+			if !tt.noSource {
+				pipeline.Source = &fakeSource{err: tt.sourceErr, calls: &calls}
+			}
+
+			batch, err := pipeline.Run(context.Background())
+			if tt.err == nil && err != nil {
+				t.Fatal(err)
+			}
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("expected %v, got %v", tt.err, err)
+			}
+			if !reflect.DeepEqual(calls, tt.want) {
+				t.Errorf("expected calls %v, got %v", tt.want, calls)
+			}
+			if batch != nil && batch.TmpDir != "" {
+				if _, err := os.Stat(batch.TmpDir); !os.IsNotExist(err) {
+					t.Errorf("tmp dir %s was not removed", batch.TmpDir)
+				}
+			}
+		})
+	}
+}
+
+func TestPipelineParsesScreen(t *testing.T) {
+	calls := []string{}
+	sink := &captureSink{}
+	pipeline := &Pipeline{
+		Source: &fakeSource{
+			batch: Batch{Data: sampleScreen(t), Filename: "zacks_custom_screen_2024-05-03.csv"},
+			calls: &calls,
+		},
+		Parse:    &ParseStage{Dates: BackfillDateResolver("")},
+		Validate: &RulesValidator{Rules: BuiltinRules(), RejectsDir: t.TempDir()},
+		Sinks:    []Sink{sink},
+	}
+
+	if _, err := pipeline.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if sink.batch == nil {
+		t.Fatal("sink was not called")
+	}
+	if sink.batch.DateStr != "2024-05-03" {
+		t.Errorf("expected event date 2024-05-03, got %s", sink.batch.DateStr)
+	}
+	if len(sink.batch.Records) != 3 {
+		t.Fatalf("expected 3 records, got %d", len(sink.batch.Records))
+	}
+	for _, record := range sink.batch.Records {
+		if record.EventDateStr != "2024-05-03" {
+			t.Errorf("%s: expected event date 2024-05-03, got %s", record.Ticker, record.EventDateStr)
+		}
+	}
+	if sink.batch.NumRejects != 0 {
+		t.Errorf("expected no rejects, got %d", sink.batch.NumRejects)
+	}
+}
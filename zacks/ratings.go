@@ -12,19 +12,55 @@ import (
 	"github.com/rs/zerolog/log"
 )
 
-func LoadRatings(ratingsData []byte, dateStr string, limit int) []*ZacksRecord {
+// RatingsOptions control how LoadRatings parses a screen
+type RatingsOptions struct {
+	// Limit truncates the result to the first Limit records when greater than zero
+	Limit int
+	// Strict returns ErrColumnDrift when the header does not match ZacksRecord
+	Strict bool
+	// NAAsZero loads "NA" values as zero, as releases before nullable fields did,
+	// instead of leaving them nil
+	NAAsZero bool
+}
+
+// LoadRatings parses a zacks screener CSV. The header is first compared with the
+// columns ZacksRecord expects; drift is logged and, when opts.Strict is set,
+// returned as ErrColumnDrift before any records are parsed. Missing ("NA")
+// numeric values are left nil unless opts.NAAsZero is set.
+func LoadRatings(ratingsData []byte, dateStr string, opts RatingsOptions) ([]*ZacksRecord, error) {
 	records := []*ZacksRecord{}
 
+	header, err := ReadHeader(ratingsData)
+	if err != nil {
+		log.Error().Err(err).Msg("could not read screener header")
+		return records, err
+	}
+
+	if drift := DetectColumnDrift(header); !drift.Empty() {
+		drift.Log()
+		log.Warn().Int("Added", len(drift.Added)).Int("Missing", len(drift.Missing)).Int("Renamed", len(drift.Renamed)).Bool("Strict", opts.Strict).Msg("screener columns do not match ZacksRecord")
+		if opts.Strict {
+			return records, ErrColumnDrift
+		}
+	}
+
+	// zacks writes missing values as "NA"; an empty value leaves the nullable
+	// fields nil
+	missing := `""`
+	if opts.NAAsZero {
+		missing = `"0"`
+	}
+
 	stringData := string(ratingsData[:])
-	stringData = strings.ReplaceAll(stringData, `"NA"`, `"0"`)
+	stringData = strings.ReplaceAll(stringData, `"NA"`, missing)
 
 	if err := gocsv.UnmarshalString(stringData, &records); err != nil {
 		log.Error().Err(err).Msg("failed to unmarshal byte data")
-		return make([]*ZacksRecord, 0)
+		return make([]*ZacksRecord, 0), err
 	}
 
-	if limit > 0 && len(records) > limit {
-		records = records[:limit]
+	if opts.Limit > 0 && len(records) > opts.Limit {
+		records = records[:opts.Limit]
 	}
 
 	date, err := time.Parse("2006-01-02", dateStr)
@@ -82,7 +118,38 @@ func LoadRatings(ratingsData []byte, dateStr string, limit int) []*ZacksRecord {
 
 	}
 
-	return records
+	return records, nil
+}
+
+// syncDateStrings sets the string date fields from their time.Time counterparts
+func (r *ZacksRecord) syncDateStrings() {
+	format := func(t time.Time) string {
+		if t.IsZero() {
+			return ""
+		}
+		return t.Format("2006-01-02")
+	}
+
+	r.EventDateStr = format(r.EventDate)
+	r.LastReportedFiscalYrStr = format(r.LastReportedFiscalYr)
+	r.LastReportedQtrDateStr = format(r.LastReportedQtrDate)
+	r.LastEpsReportDateStr = format(r.LastEpsReportDate)
+	r.NextEpsReportDateStr = format(r.NextEpsReportDate)
+}
+
+// syncDates parses the YYYY-MM-DD string date fields, as stored in parquet,
+// into their time.Time counterparts
+func (r *ZacksRecord) syncDates() {
+	parse := func(s string) time.Time {
+		t, _ := time.Parse("2006-01-02", s)
+		return t
+	}
+
+	r.EventDate = parse(r.EventDateStr)
+	r.LastReportedFiscalYr = parse(r.LastReportedFiscalYrStr)
+	r.LastReportedQtrDate = parse(r.LastReportedQtrDateStr)
+	r.LastEpsReportDate = parse(r.LastEpsReportDateStr)
+	r.NextEpsReportDate = parse(r.NextEpsReportDateStr)
 }
 
 func isValidExchange(record *ZacksRecord) bool {
@@ -91,9 +158,20 @@ func isValidExchange(record *ZacksRecord) bool {
 }
 
 func EnrichWithFigi(records []*ZacksRecord) []*ZacksRecord {
+	tickerMap, err := LoadTickerMap()
+	if err != nil {
+		return records
+	}
+
+	return ApplyFigi(records, tickerMap)
+}
+
+// LoadTickerMap returns all active assets that have a composite figi keyed by ticker
+func LoadTickerMap() (map[string]*Ticker, error) {
 	conn, err := pgx.Connect(context.Background(), viper.GetString("database.url"))
 	if err != nil {
 		log.Error().Err(err).Msg("Could not connect to database")
+		return nil, err
 	}
 	defer conn.Close(context.Background())
 
@@ -103,6 +181,7 @@ func EnrichWithFigi(records []*ZacksRecord) []*ZacksRecord {
 	rows, err := conn.Query(context.Background(), "SELECT ticker, name, composite_figi FROM assets WHERE active='t' AND composite_figi IS NOT NULL")
 	if err != nil {
 		log.Error().Err(err).Msg("Failed to retrieve tickers from database")
+		return nil, err
 	}
 
 	for rows.Next() {
@@ -114,6 +193,11 @@ func EnrichWithFigi(records []*ZacksRecord) []*ZacksRecord {
 		tickerMap[ticker.Ticker] = &ticker
 	}
 
+	return tickerMap, nil
+}
+
+// ApplyFigi sets the composite figi of each record found in tickerMap
+func ApplyFigi(records []*ZacksRecord, tickerMap map[string]*Ticker) []*ZacksRecord {
 	for _, r := range records {
 		if ticker, ok := tickerMap[r.Ticker]; ok {
 			r.CompositeFigi = ticker.CompositeFigi
@@ -0,0 +1,96 @@
+/*
+Copyright 2022
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package zacks
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestLoadRatings(t *testing.T) {
+	sample := sampleScreen(t)
+	drifted := bytes.Replace(sample, []byte(`"Beta",`), []byte(`"Beta (60 mo)",`), 1)
+
+	tests := []struct {
+		name     string
+		data     []byte
+		opts     RatingsOptions
+		tickers  []string
+		exmpRank *int
+		err      error
+	}{
+		{"NA is nil", sample, RatingsOptions{}, []string{"AAPL", "MSFT", "EXMP"}, nil, nil},
+		{"NA as zero", sample, RatingsOptions{NAAsZero: true}, []string{"AAPL", "MSFT", "EXMP"}, new(int), nil},
+		{"limit", sample, RatingsOptions{Limit: 2}, []string{"AAPL", "MSFT"}, nil, nil},
+		{"drift is tolerated", drifted, RatingsOptions{}, []string{"AAPL", "MSFT", "EXMP"}, nil, nil},
+		{"strict rejects drift", drifted, RatingsOptions{Strict: true}, nil, nil, ErrColumnDrift},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			records, err := LoadRatings(tt.data, "2024-05-03", tt.opts)
+			if tt.err != nil {
+				if !errors.Is(err, tt.err) {
+					t.Fatalf("expected %v, got %v", tt.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(records) != len(tt.tickers) {
+				t.Fatalf("expected %d records, got %d", len(tt.tickers), len(records))
+			}
+			for idx, record := range records {
+				if record.Ticker != tt.tickers[idx] {
+					t.Errorf("record %d: expected %s, got %s", idx, tt.tickers[idx], record.Ticker)
+				}
+				if record.EventDateStr != "2024-05-03" || record.EventDate.Format("2006-01-02") != "2024-05-03" {
+					t.Errorf("%s: event date not set, got %q", record.Ticker, record.EventDateStr)
+				}
+				if record.LastReportedQtrDateStr != "2024-03-01" {
+					t.Errorf("%s: expected last reported quarter 2024-03-01, got %s", record.Ticker, record.LastReportedQtrDateStr)
+				}
+			}
+
+			aapl := records[0]
+			if aapl.ZacksRank == nil || *aapl.ZacksRank != 3 {
+				t.Errorf("AAPL: expected zacks rank 3, got %v", aapl.ZacksRank)
+			}
+			if aapl.LastClose == nil || *aapl.LastClose != 183.38 {
+				t.Errorf("AAPL: expected last close 183.38, got %v", aapl.LastClose)
+			}
+
+			if len(records) < 3 {
+				return
+			}
+			exmp := records[2]
+			if tt.exmpRank == nil {
+				if exmp.ZacksRank != nil || exmp.LastClose != nil {
+					t.Errorf("EXMP: expected NA to load as nil, got rank %v and close %v", exmp.ZacksRank, exmp.LastClose)
+				}
+			} else if exmp.ZacksRank == nil || *exmp.ZacksRank != *tt.exmpRank {
+				t.Errorf("EXMP: expected zacks rank %d, got %v", *tt.exmpRank, exmp.ZacksRank)
+			}
+			if exmp.ValueScore != "A" {
+				t.Errorf("EXMP: expected value score A, got %q", exmp.ValueScore)
+			}
+		})
+	}
+}
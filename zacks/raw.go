@@ -0,0 +1,152 @@
+/*
+Copyright 2022
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package zacks
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/rs/zerolog/log"
+	"github.com/xitongsys/parquet-go-source/local"
+	"github.com/xitongsys/parquet-go/parquet"
+	"github.com/xitongsys/parquet-go/writer"
+)
+
+// ParseRawCSV reads a screen export without mapping it onto ZacksRecord. The
+// header is converted to snake_case column names and every row is padded or
+// truncated to the width of the header.
+func ParseRawCSV(data []byte) (header []string, rows [][]string, err error) {
+	data = bytes.TrimPrefix(data, []byte("\ufeff"))
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.FieldsPerRecord = -1
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		log.Error().Err(err).Msg("could not parse screen CSV")
+		return nil, nil, err
+	}
+
+	if len(records) == 0 {
+		return nil, nil, ErrNoRatings
+	}
+
+	header = RawColumnNames(records[0])
+	rows = make([][]string, 0, len(records)-1)
+	for _, record := range records[1:] {
+		row := make([]string, len(header))
+		copy(row, record)
+		rows = append(rows, row)
+	}
+
+	return header, rows, nil
+}
+
+// RawColumnNames converts screen headers such as "Last Close" or "% Change
+// F1 Est. (4 weeks)" into parquet-friendly names like last_close and
+// change_f1_est_4_weeks. Empty and duplicate names are numbered.
+func RawColumnNames(header []string) []string {
+	names := make([]string, len(header))
+	seen := make(map[string]int, len(header))
+	for idx, col := range header {
+		var sb strings.Builder
+		underscore := false
+		for _, r := range strings.ToLower(normalizeColumn(col)) {
+			if unicode.IsLetter(r) || unicode.IsDigit(r) {
+				if underscore && sb.Len() > 0 {
+					sb.WriteByte('_')
+				}
+				underscore = false
+				sb.WriteRune(r)
+				continue
+			}
+			underscore = true
+		}
+
+		name := sb.String()
+		if name == "" {
+			name = fmt.Sprintf("column_%d", idx+1)
+		}
+		if name[0] >= '0' && name[0] <= '9' {
+			name = "c_" + name
+		}
+		// event_date is added by SaveRawToParquet
+		if name == "event_date" {
+			name = "screen_event_date"
+		}
+
+		seen[name]++
+		if seen[name] > 1 {
+			name = fmt.Sprintf("%s_%d", name, seen[name])
+		}
+		names[idx] = name
+	}
+	return names
+}
+
+// SaveRawToParquet writes the rows of a SchemaRaw screen to fn. Every column is
+// an optional string; empty and NA values are written as null. An event_date
+// column is added in front of the screen's columns.
+func SaveRawToParquet(header []string, rows [][]string, dateStr string, fn string) error {
+	md := make([]string, 0, len(header)+1)
+	md = append(md, "name=event_date, type=BYTE_ARRAY, convertedtype=UTF8, encoding=PLAIN_DICTIONARY")
+	for _, col := range header {
+		md = append(md, fmt.Sprintf("name=%s, type=BYTE_ARRAY, convertedtype=UTF8, repetitiontype=OPTIONAL", col))
+	}
+
+	fh, err := local.NewLocalFileWriter(fn)
+	if err != nil {
+		log.Error().Err(err).Str("FileName", fn).Msg("cannot create local file")
+		return err
+	}
+	defer fh.Close()
+
+	pw, err := writer.NewCSVWriter(md, fh, 4)
+	if err != nil {
+		log.Error().Err(err).Msg("Parquet write failed")
+		return err
+	}
+
+	pw.CompressionType = parquet.CompressionCodec_ZSTD
+
+	eventDate := dateStr
+	for _, row := range rows {
+		values := make([]*string, len(header)+1)
+		values[0] = &eventDate
+		for idx := range header {
+			value := strings.TrimSpace(row[idx])
+			if value != "" && value != "NA" {
+				values[idx+1] = &value
+			}
+		}
+
+		if err = pw.WriteString(values); err != nil {
+			log.Error().Err(err).Msg("Parquet write failed for row")
+			return err
+		}
+	}
+
+	if err = pw.WriteStop(); err != nil {
+		log.Error().Err(err).Msg("Parquet write failed")
+		return err
+	}
+
+	log.Info().Int("NumRecords", len(rows)).Str("FileName", fn).Msg("Parquet write finished")
+	return nil
+}
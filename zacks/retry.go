@@ -0,0 +1,96 @@
+/*
+Copyright 2022
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package zacks
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/viper"
+)
+
+// Backoff computes the wait between download attempts. The delay doubles after
+// each attempt, starting at Initial and capped at Max, and a random jitter of
+// up to half the delay is subtracted so that retries don't run in lock step.
+type Backoff struct {
+	Initial time.Duration
+	Max     time.Duration
+}
+
+// ConfiguredBackoff reads zacks.retry_delay and zacks.retry_max_delay
+func ConfiguredBackoff() *Backoff {
+	return &Backoff{
+		Initial: viper.GetDuration("zacks.retry_delay"),
+		Max:     viper.GetDuration("zacks.retry_max_delay"),
+	}
+}
+
+// Delay returns how long to wait before retry number attempt, counting from 1
+func (backoff *Backoff) Delay(attempt int) time.Duration {
+	if backoff == nil || backoff.Initial <= 0 {
+		return 0
+	}
+
+	delay := backoff.Initial
+	for ii := 1; ii < attempt; ii++ {
+		delay *= 2
+		if backoff.Max > 0 && delay >= backoff.Max {
+			break
+		}
+	}
+	if backoff.Max > 0 && delay > backoff.Max {
+		delay = backoff.Max
+	}
+
+	return delay - rand.N(delay/2+1)
+}
+
+// Wait sleeps for Delay(attempt) or until ctx is done, in which case it
+// returns the context's error
+func (backoff *Backoff) Wait(ctx context.Context, attempt int) error {
+	delay := backoff.Delay(attempt)
+	if delay <= 0 {
+		return ctx.Err()
+	}
+
+	log.Info().Int("Attempt", attempt).Dur("Delay", delay).Msg("waiting before retrying download")
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Retryable reports whether trying again may fix err. Login failures that need
+// someone to act, i.e. bad credentials, an expired subscription or a bot
+// challenge, are not retryable; retrying a bot challenge only makes it worse.
+// Neither is a cancelled context or one past its deadline.
+func Retryable(err error) bool {
+	return !errors.Is(err, ErrBadCredentials) &&
+		!errors.Is(err, ErrSubscriptionExpired) &&
+		!errors.Is(err, ErrBotChallenge) &&
+		!errors.Is(err, context.Canceled) &&
+		!errors.Is(err, context.DeadlineExceeded)
+}
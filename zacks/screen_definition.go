@@ -0,0 +1,206 @@
+/*
+Copyright 2022
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package zacks
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/viper"
+)
+
+// ErrScreenDrift is returned by screen verify when the live screen differs from its definition
+var ErrScreenDrift = errors.New("saved screen does not match its definition")
+
+// Criterion is one filter of a saved screen, e.g. Zacks Rank <= 3
+type Criterion struct {
+	Field    string `mapstructure:"field" json:"field"`
+	Operator string `mapstructure:"operator" json:"operator"`
+	Value    string `mapstructure:"value" json:"value"`
+}
+
+func (criterion *Criterion) key() string {
+	return strings.Join([]string{
+		normalizeColumn(criterion.Field),
+		strings.TrimSpace(criterion.Operator),
+		strings.TrimSpace(criterion.Value),
+	}, "\x00")
+}
+
+// String formats the criterion as it is shown on the screener
+func (criterion *Criterion) String() string {
+	return fmt.Sprintf("%s %s %s", criterion.Field, criterion.Operator, criterion.Value)
+}
+
+// ScreenDefinition is the criteria and output columns of a saved screen,
+// kept in a file so the screen can be reviewed and recreated
+type ScreenDefinition struct {
+	// Title is the name of the saved screen on the My Screens tab
+	Title string `mapstructure:"title" json:"title"`
+	// ID of the saved screen; when 0 the screen is found by Title
+	ID       int          `mapstructure:"id" json:"id,omitempty"`
+	Criteria []*Criterion `mapstructure:"criteria" json:"criteria"`
+	// Columns are the output columns of the screen in the order they are exported
+	Columns []string `mapstructure:"columns" json:"columns"`
+}
+
+// LoadScreenDefinition reads a screen definition from a toml, yaml or json file
+func LoadScreenDefinition(fn string) (*ScreenDefinition, error) {
+	v := viper.New()
+	v.SetConfigFile(fn)
+	if err := v.ReadInConfig(); err != nil {
+		log.Error().Err(err).Str("FileName", fn).Msg("could not read screen definition")
+		return nil, err
+	}
+
+	def := &ScreenDefinition{}
+	if err := v.Unmarshal(def); err != nil {
+		return nil, fmt.Errorf("invalid screen definition %s: %w", fn, err)
+	}
+
+	if def.Title == "" {
+		return nil, fmt.Errorf("screen definition %s: title is required", fn)
+	}
+	if len(def.Columns) == 0 {
+		return nil, fmt.Errorf("screen definition %s: no columns", fn)
+	}
+
+	seen := make(map[string]bool, len(def.Columns))
+	for _, col := range def.Columns {
+		col = normalizeColumn(col)
+		if seen[col] {
+			return nil, fmt.Errorf("screen definition %s: column %q is listed more than once", fn, col)
+		}
+		seen[col] = true
+	}
+
+	for idx, criterion := range def.Criteria {
+		if criterion.Field == "" || criterion.Operator == "" {
+			return nil, fmt.Errorf("screen definition %s: criteria[%d] needs a field and an operator", fn, idx)
+		}
+	}
+
+	return def, nil
+}
+
+// LoadDefinition reads the definition file of the screen. The screen's id is
+// used when the file does not set one.
+func (screen *Screen) LoadDefinition() (*ScreenDefinition, error) {
+	if screen.Definition == "" {
+		return nil, fmt.Errorf("screen %s has no definition file", screen.Name)
+	}
+
+	def, err := LoadScreenDefinition(screen.Definition)
+	if err != nil {
+		return nil, err
+	}
+
+	if def.ID == 0 {
+		def.ID = screen.ID
+	}
+	return def, nil
+}
+
+// ScreenDefinitionDiff lists what has to change for a live screen to match its definition
+type ScreenDefinitionDiff struct {
+	// AddCriteria are in the definition but not on the live screen
+	AddCriteria []*Criterion
+	// RemoveCriteria are on the live screen but not in the definition
+	RemoveCriteria []*Criterion
+	AddColumns     []string
+	RemoveColumns  []string
+	// Reordered is set when both have the same columns in a different order
+	Reordered bool
+}
+
+// Empty reports whether the live screen matches the definition
+func (diff *ScreenDefinitionDiff) Empty() bool {
+	return len(diff.AddCriteria) == 0 && len(diff.RemoveCriteria) == 0 &&
+		len(diff.AddColumns) == 0 && len(diff.RemoveColumns) == 0 && !diff.Reordered
+}
+
+// Log writes one line per difference
+func (diff *ScreenDefinitionDiff) Log() {
+	for _, criterion := range diff.AddCriteria {
+		log.Warn().Str("Criterion", criterion.String()).Msg("criterion missing from live screen")
+	}
+	for _, criterion := range diff.RemoveCriteria {
+		log.Warn().Str("Criterion", criterion.String()).Msg("live screen has criterion not in definition")
+	}
+	for _, col := range diff.AddColumns {
+		log.Warn().Str("Column", col).Msg("column missing from live screen")
+	}
+	for _, col := range diff.RemoveColumns {
+		log.Warn().Str("Column", col).Msg("live screen has column not in definition")
+	}
+	if diff.Reordered {
+		log.Warn().Msg("live screen columns are in a different order than the definition")
+	}
+}
+
+// DiffScreenDefinition compares the live screen with the wanted definition.
+// Column names are compared after trimming whitespace.
+func DiffScreenDefinition(want, live *ScreenDefinition) *ScreenDefinitionDiff {
+	diff := &ScreenDefinitionDiff{}
+
+	liveCriteria := make(map[string]bool, len(live.Criteria))
+	for _, criterion := range live.Criteria {
+		liveCriteria[criterion.key()] = true
+	}
+	wantCriteria := make(map[string]bool, len(want.Criteria))
+	for _, criterion := range want.Criteria {
+		wantCriteria[criterion.key()] = true
+		if !liveCriteria[criterion.key()] {
+			diff.AddCriteria = append(diff.AddCriteria, criterion)
+		}
+	}
+	for _, criterion := range live.Criteria {
+		if !wantCriteria[criterion.key()] {
+			diff.RemoveCriteria = append(diff.RemoveCriteria, criterion)
+		}
+	}
+
+	liveColumns := make(map[string]bool, len(live.Columns))
+	for _, col := range live.Columns {
+		liveColumns[normalizeColumn(col)] = true
+	}
+	wantColumns := make(map[string]bool, len(want.Columns))
+	for _, col := range want.Columns {
+		wantColumns[normalizeColumn(col)] = true
+		if !liveColumns[normalizeColumn(col)] {
+			diff.AddColumns = append(diff.AddColumns, normalizeColumn(col))
+		}
+	}
+	for _, col := range live.Columns {
+		if !wantColumns[normalizeColumn(col)] {
+			diff.RemoveColumns = append(diff.RemoveColumns, normalizeColumn(col))
+		}
+	}
+
+	if len(diff.AddColumns) == 0 && len(diff.RemoveColumns) == 0 {
+		for idx := range want.Columns {
+			if normalizeColumn(want.Columns[idx]) != normalizeColumn(live.Columns[idx]) {
+				diff.Reordered = true
+				break
+			}
+		}
+	}
+
+	return diff
+}
@@ -0,0 +1,334 @@
+/*
+Copyright 2022
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package zacks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/playwright-community/playwright-go"
+	"github.com/rs/zerolog/log"
+)
+
+// ErrSavedScreenNotFound is returned when the My Screens tab has no screen with the definition's id or title
+var ErrSavedScreenNotFound = errors.New("saved screen not found")
+
+// selectors of the saved screen editor inside the #screenerContent frame
+const (
+	newScreenSelector       = "#btn_new_screen"
+	screenEditorSelector    = "#screen_editor"
+	screenNameSelector      = "#screen_name"
+	criteriaRowSelector     = "#criteria_table tbody tr"
+	criteriaFieldSelector   = "#criteria_field"
+	criteriaOpSelector      = "#criteria_operator"
+	criteriaValueSelector   = "#criteria_value"
+	addCriteriaSelector     = "#btn_add_criteria"
+	removeCriteriaSelector  = ".remove_criteria"
+	outputColumnSelector    = "#output_columns li"
+	columnSelectSelector    = "#column_select"
+	addColumnSelector       = "#btn_add_column"
+	removeColumnSelector    = ".remove_column"
+	saveScreenSelector      = "#btn_save_screen"
+	saveStatusSavedSelector = "#save_status:has-text('Saved')"
+)
+
+// ReadLiveScreen logs in and returns the criteria and output columns of the
+// saved screen described by def
+func ReadLiveScreen(ctx context.Context, def *ScreenDefinition) (live *ScreenDefinition, err error) {
+	page, _, stop, err := startLoggedIn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer stop()
+	defer func() { err = contextErr(ctx, err) }()
+
+	frame, err := openScreenEditor(page, def, false)
+	if err != nil {
+		return nil, err
+	}
+
+	return readScreenEditor(frame)
+}
+
+// SyncScreen creates the saved screen described by def, or updates its criteria
+// and output columns, and returns the changes that were made. The saved screen
+// is read back afterwards and ErrScreenDrift is returned if it still differs.
+func SyncScreen(ctx context.Context, def *ScreenDefinition) (diff *ScreenDefinitionDiff, err error) {
+	page, _, stop, err := startLoggedIn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer stop()
+	defer func() { err = contextErr(ctx, err) }()
+
+	frame, err := openScreenEditor(page, def, true)
+	if err != nil {
+		return nil, err
+	}
+
+	live, err := readScreenEditor(frame)
+	if err != nil {
+		return nil, err
+	}
+
+	diff = DiffScreenDefinition(def, live)
+	if diff.Empty() && live.Title == def.Title {
+		log.Info().Str("Title", def.Title).Int("ID", live.ID).Msg("saved screen already matches definition")
+		return diff, nil
+	}
+	diff.Log()
+
+	if err := applyScreenDiff(frame, def, live, diff); err != nil {
+		return diff, err
+	}
+
+	id, err := saveScreenEditor(frame, def.Title)
+	if err != nil {
+		return diff, err
+	}
+	if def.ID == 0 {
+		log.Info().Str("Title", def.Title).Int("ID", id).Msg("saved screen; set id in the definition and screens config to select it by id")
+	} else if id != def.ID {
+		log.Warn().Int("Expected", def.ID).Int("ID", id).Msg("screen was saved under a different id")
+	}
+
+	// read the screen back to confirm the site accepted every change
+	saved := *def
+	saved.ID = id
+	if frame, err = openScreenEditor(page, &saved, false); err != nil {
+		return diff, err
+	}
+	if live, err = readScreenEditor(frame); err != nil {
+		return diff, err
+	}
+	if remaining := DiffScreenDefinition(def, live); !remaining.Empty() {
+		remaining.Log()
+		return diff, ErrScreenDrift
+	}
+
+	log.Info().Str("Title", def.Title).Int("ID", id).Msg("saved screen matches definition")
+	return diff, nil
+}
+
+// openScreenEditor opens the editor of the saved screen def describes, or of a
+// new screen when it does not exist and create is set
+func openScreenEditor(page playwright.Page, def *ScreenDefinition, create bool) (playwright.FrameLocator, error) {
+	log.Info().Str("Title", def.Title).Int("ID", def.ID).Msg("open saved screen")
+
+	if _, err := page.Goto(StockScreenerURL(), playwright.PageGotoOptions{
+		WaitUntil: playwright.WaitUntilStateNetworkidle,
+	}); err != nil {
+		log.Error().Err(err).Msg("could not load stock screener page")
+		return nil, err
+	}
+
+	frame := page.FrameLocator("#screenerContent")
+
+	if err := frame.Locator("#my-screen-tab").Click(); err != nil {
+		log.Error().Err(err).Msg("click tab button failed")
+		return nil, err
+	}
+
+	button := savedScreenButton(frame, "btn_edit_", def.ID, def.Title)
+	count, err := button.Count()
+	if err != nil {
+		log.Error().Err(err).Msg("could not search saved screens")
+		return nil, err
+	}
+
+	switch {
+	case count > 0:
+		err = button.Click()
+	case create:
+		log.Info().Str("Title", def.Title).Msg("saved screen does not exist; creating it")
+		err = frame.Locator(newScreenSelector).Click()
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrSavedScreenNotFound, def.Title)
+	}
+	if err != nil {
+		log.Error().Err(err).Msg("could not open screen editor")
+		return nil, err
+	}
+
+	if err := frame.Locator(screenEditorSelector).WaitFor(); err != nil {
+		log.Error().Err(err).Msg("wait for screen editor failed")
+		return nil, err
+	}
+
+	return frame, nil
+}
+
+// readScreenEditor scrapes the open screen editor
+func readScreenEditor(frame playwright.FrameLocator) (*ScreenDefinition, error) {
+	live := &ScreenDefinition{}
+
+	var err error
+	if live.Title, err = frame.Locator(screenNameSelector).InputValue(); err != nil {
+		return nil, err
+	}
+
+	if id, err := frame.Locator(screenEditorSelector).GetAttribute("data-screen-id"); err == nil && id != "" {
+		live.ID, _ = strconv.Atoi(id)
+	}
+
+	rows, err := frame.Locator(criteriaRowSelector).All()
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		criterion, err := readCriterion(row)
+		if err != nil {
+			log.Error().Err(err).Msg("could not read screen criterion")
+			return nil, err
+		}
+		live.Criteria = append(live.Criteria, criterion)
+	}
+
+	items, err := frame.Locator(outputColumnSelector).All()
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range items {
+		col, err := item.GetAttribute("data-column")
+		if err != nil {
+			log.Error().Err(err).Msg("could not read output column")
+			return nil, err
+		}
+		live.Columns = append(live.Columns, col)
+	}
+
+	log.Info().Str("Title", live.Title).Int("ID", live.ID).Int("Criteria", len(live.Criteria)).Int("Columns", len(live.Columns)).Msg("read saved screen")
+	return live, nil
+}
+
+func readCriterion(row playwright.Locator) (*Criterion, error) {
+	criterion := &Criterion{}
+	for _, cell := range []struct {
+		class string
+		value *string
+	}{
+		{"td.criteria_field", &criterion.Field},
+		{"td.criteria_operator", &criterion.Operator},
+		{"td.criteria_value", &criterion.Value},
+	} {
+		text, err := row.Locator(cell.class).InnerText()
+		if err != nil {
+			return nil, err
+		}
+		*cell.value = text
+	}
+	return criterion, nil
+}
+
+// applyScreenDiff edits the open screen until it matches def. Columns can only
+// be appended, so every column from the first one out of place is removed and
+// the rest are added back in definition order.
+func applyScreenDiff(frame playwright.FrameLocator, def, live *ScreenDefinition, diff *ScreenDefinitionDiff) error {
+	for _, criterion := range diff.RemoveCriteria {
+		if err := removeCriterion(frame, criterion); err != nil {
+			return err
+		}
+	}
+
+	for _, criterion := range diff.AddCriteria {
+		log.Info().Str("Criterion", criterion.String()).Msg("adding criterion")
+		if _, err := frame.Locator(criteriaFieldSelector).SelectOption(playwright.SelectOptionValues{Labels: &[]string{criterion.Field}}); err != nil {
+			log.Error().Err(err).Str("Field", criterion.Field).Msg("could not select criterion field")
+			return err
+		}
+		if _, err := frame.Locator(criteriaOpSelector).SelectOption(playwright.SelectOptionValues{Labels: &[]string{criterion.Operator}}); err != nil {
+			log.Error().Err(err).Str("Operator", criterion.Operator).Msg("could not select criterion operator")
+			return err
+		}
+		if err := frame.Locator(criteriaValueSelector).Fill(criterion.Value); err != nil {
+			return err
+		}
+		if err := frame.Locator(addCriteriaSelector).Click(); err != nil {
+			return err
+		}
+	}
+
+	keep := 0
+	for keep < len(live.Columns) && keep < len(def.Columns) && normalizeColumn(live.Columns[keep]) == normalizeColumn(def.Columns[keep]) {
+		keep++
+	}
+
+	for ii := len(live.Columns) - 1; ii >= keep; ii-- {
+		if err := frame.Locator(outputColumnSelector).Nth(ii).Locator(removeColumnSelector).Click(); err != nil {
+			log.Error().Err(err).Str("Column", live.Columns[ii]).Msg("could not remove output column")
+			return err
+		}
+	}
+
+	for _, col := range def.Columns[keep:] {
+		if _, err := frame.Locator(columnSelectSelector).SelectOption(playwright.SelectOptionValues{Labels: &[]string{normalizeColumn(col)}}); err != nil {
+			log.Error().Err(err).Str("Column", col).Msg("could not select output column")
+			return err
+		}
+		if err := frame.Locator(addColumnSelector).Click(); err != nil {
+			return err
+		}
+	}
+
+	log.Info().Int("Kept", keep).Int("Removed", len(live.Columns)-keep).Int("Added", len(def.Columns)-keep).Msg("updated output columns")
+	return nil
+}
+
+func removeCriterion(frame playwright.FrameLocator, criterion *Criterion) error {
+	rows, err := frame.Locator(criteriaRowSelector).All()
+	if err != nil {
+		return err
+	}
+
+	for _, row := range rows {
+		live, err := readCriterion(row)
+		if err != nil {
+			return err
+		}
+		if live.key() == criterion.key() {
+			log.Info().Str("Criterion", criterion.String()).Msg("removing criterion")
+			return row.Locator(removeCriteriaSelector).Click()
+		}
+	}
+
+	return nil
+}
+
+// saveScreenEditor saves the open screen as title and returns its id
+func saveScreenEditor(frame playwright.FrameLocator, title string) (int, error) {
+	if err := frame.Locator(screenNameSelector).Fill(title); err != nil {
+		return 0, err
+	}
+
+	if err := frame.Locator(saveScreenSelector).Click(); err != nil {
+		log.Error().Err(err).Msg("click save button failed")
+		return 0, err
+	}
+
+	if err := frame.Locator(saveStatusSavedSelector).WaitFor(); err != nil {
+		log.Error().Err(err).Msg("wait for screen to save failed")
+		return 0, err
+	}
+
+	id, err := frame.Locator(screenEditorSelector).GetAttribute("data-screen-id")
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(id)
+}
@@ -0,0 +1,163 @@
+/*
+Copyright 2022
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package zacks
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/penny-vault/import-zacks-rank/storage"
+	"github.com/spf13/viper"
+)
+
+// Record schemas a screen can be parsed with
+const (
+	// SchemaRatings parses the screen into ZacksRecord; the screen must have the
+	// columns of the main ratings screen
+	SchemaRatings = "ratings"
+	// SchemaRaw keeps every column of the screen as text
+	SchemaRaw = "raw"
+)
+
+// DefaultScreenID is the saved screen downloaded when no screens are configured
+const DefaultScreenID = 137005
+
+// Screen is a saved Zacks stock screen to download
+type Screen struct {
+	// Name identifies the screen in logs and on the command line
+	Name string `mapstructure:"name"`
+	// ID is the id of the saved screen; Title is used to find it when ID is 0
+	ID    int    `mapstructure:"id"`
+	Title string `mapstructure:"title"`
+	// Schema is SchemaRatings or SchemaRaw
+	Schema string `mapstructure:"schema"`
+	// Dataset is the name the screen is archived under; it defaults to Name
+	Dataset string `mapstructure:"dataset"`
+	// Table is the database table ratings screens are loaded into; when empty
+	// the screen is not loaded into the database
+	Table string `mapstructure:"table"`
+	// Definition is the file the screen's criteria and columns are kept in,
+	// used by screen sync and screen verify
+	Definition string `mapstructure:"definition"`
+}
+
+// DefaultScreen returns the main ratings screen
+func DefaultScreen() *Screen {
+	return &Screen{
+		Name:    "ratings",
+		ID:      DefaultScreenID,
+		Schema:  SchemaRatings,
+		Dataset: storage.DatasetRatings,
+		Table:   RatingsTable,
+	}
+}
+
+var screenNameRegex = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+// ConfiguredScreens returns the screens listed under [[screens]], or the
+// default screen when none are configured
+func ConfiguredScreens() ([]*Screen, error) {
+	var screens []*Screen
+	if err := viper.UnmarshalKey("screens", &screens); err != nil {
+		return nil, fmt.Errorf("invalid screens: %w", err)
+	}
+
+	if len(screens) == 0 {
+		return []*Screen{DefaultScreen()}, nil
+	}
+
+	names := make(map[string]bool, len(screens))
+	datasets := make(map[string]bool, len(screens))
+	for idx, screen := range screens {
+		if screen.Name == "" {
+			return nil, fmt.Errorf("screens[%d]: name is required", idx)
+		}
+		if !screenNameRegex.MatchString(screen.Name) {
+			return nil, fmt.Errorf("screen %s: name may only contain lower case letters, digits, '-' and '_'", screen.Name)
+		}
+		if names[screen.Name] {
+			return nil, fmt.Errorf("screen %s: name is used more than once", screen.Name)
+		}
+		names[screen.Name] = true
+
+		if screen.ID == 0 && screen.Title == "" {
+			return nil, fmt.Errorf("screen %s: id or title is required", screen.Name)
+		}
+
+		switch screen.Schema {
+		case "":
+			screen.Schema = SchemaRatings
+		case SchemaRatings, SchemaRaw:
+		default:
+			return nil, fmt.Errorf("screen %s: unknown schema %q, expected %s or %s", screen.Name, screen.Schema, SchemaRatings, SchemaRaw)
+		}
+
+		if screen.Dataset == "" {
+			screen.Dataset = screen.Name
+		}
+		if !screenNameRegex.MatchString(screen.Dataset) {
+			return nil, fmt.Errorf("screen %s: dataset may only contain lower case letters, digits, '-' and '_'", screen.Name)
+		}
+		if datasets[screen.Dataset] {
+			return nil, fmt.Errorf("screen %s: dataset %s is used by another screen", screen.Name, screen.Dataset)
+		}
+		datasets[screen.Dataset] = true
+
+		if screen.Table != "" && screen.Schema != SchemaRatings {
+			return nil, fmt.Errorf("screen %s: only %s screens can be loaded into a table", screen.Name, SchemaRatings)
+		}
+	}
+
+	return screens, nil
+}
+
+// FindScreen returns the configured screen called name; an empty name selects
+// the first configured screen
+func FindScreen(name string) (*Screen, error) {
+	screens, err := ConfiguredScreens()
+	if err != nil {
+		return nil, err
+	}
+
+	if name == "" {
+		return screens[0], nil
+	}
+
+	for _, screen := range screens {
+		if screen.Name == name {
+			return screen, nil
+		}
+	}
+
+	return nil, fmt.Errorf("%w: %s", ErrUnknownScreen, name)
+}
+
+// SchemaVersion is the version of the parquet schema the screen is archived with
+func (screen *Screen) SchemaVersion() int {
+	if screen.Schema == SchemaRaw {
+		return RawSchemaVersion
+	}
+	return RatingsSchemaVersion
+}
+
+// String identifies the screen in logs
+func (screen *Screen) String() string {
+	if screen.ID != 0 {
+		return fmt.Sprintf("%s (%d)", screen.Name, screen.ID)
+	}
+	return fmt.Sprintf("%s (%q)", screen.Name, screen.Title)
+}
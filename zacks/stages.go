@@ -0,0 +1,427 @@
+/*
+Copyright 2022
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package zacks
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/penny-vault/import-zacks-rank/storage"
+	"github.com/rs/zerolog/log"
+)
+
+// Sources
+
+// ScreensSource downloads several saved screens in one browser session. Screens
+// that fail are downloaded again, up to MaxRetries attempts in total and
+// waiting Backoff between attempts, unless the error is not Retryable.
+type ScreensSource struct {
+	Screens    []*Screen
+	MaxRetries int
+	// Backoff may be nil to retry immediately
+	Backoff *Backoff
+}
+
+// FetchAll returns a batch for each screen that downloaded. The error names the
+// screens that failed every attempt; the other batches are still returned.
+func (s *ScreensSource) FetchAll(ctx context.Context) ([]*Batch, error) {
+	attempts := s.MaxRetries
+	if attempts < 1 {
+		attempts = 1
+	}
+
+	pending := s.Screens
+	byName := make(map[string]*Batch, len(s.Screens))
+
+	var err error
+	for ii := 0; ii < attempts && len(pending) > 0; ii++ {
+		if ii > 0 {
+			if waitErr := s.Backoff.Wait(ctx, ii); waitErr != nil {
+				err = waitErr
+				break
+			}
+		}
+
+		var downloads []*ScreenDownload
+		downloads, err = DownloadScreens(ctx, pending)
+		for _, download := range downloads {
+			byName[download.Screen.Name] = &Batch{Data: download.Data, Filename: download.Filename, Screen: download.Screen}
+		}
+
+		remaining := make([]*Screen, 0, len(pending))
+		for _, screen := range pending {
+			if _, ok := byName[screen.Name]; !ok {
+				remaining = append(remaining, screen)
+			}
+		}
+		pending = remaining
+
+		if err != nil && !Retryable(err) {
+			log.Error().Err(err).Msg("download failed; not retrying")
+			break
+		}
+	}
+
+	// keep the configured order
+	batches := make([]*Batch, 0, len(byName))
+	for _, screen := range s.Screens {
+		if batch, ok := byName[screen.Name]; ok {
+			batches = append(batches, batch)
+		}
+	}
+
+	if len(pending) > 0 {
+		return batches, err
+	}
+	return batches, nil
+}
+
+// BatchSource hands an already fetched batch to a pipeline
+type BatchSource struct {
+	Batch *Batch
+}
+
+func (s *BatchSource) Name() string {
+	if s.Batch.Screen != nil {
+		return "screen " + s.Batch.Screen.Name
+	}
+	return "batch"
+}
+
+func (s *BatchSource) Fetch(ctx context.Context) (*Batch, error) {
+	return s.Batch, nil
+}
+
+// FileSource reads a previously downloaded screen from disk
+type FileSource struct {
+	Path string
+	// Screen the file was downloaded from; nil means the default screen
+	Screen *Screen
+}
+
+func (s *FileSource) Name() string { return "file" }
+
+func (s *FileSource) Fetch(ctx context.Context) (*Batch, error) {
+	data, err := os.ReadFile(s.Path)
+	if err != nil {
+		log.Error().Err(err).Str("FileName", s.Path).Msg("could not read input file")
+		return nil, err
+	}
+
+	return &Batch{Data: data, Filename: filepath.Base(s.Path), Path: s.Path, Screen: s.Screen}, nil
+}
+
+// ArchiveSource reads a parquet file written by ParquetSink back from the
+// archive, e.g. to restore the database; pair it with ParquetParseStage
+type ArchiveSource struct {
+	Archiver *storage.Archiver
+	Date     time.Time
+	// Key is looked up with Archiver.Locate when empty
+	Key string
+}
+
+func (s *ArchiveSource) Name() string { return "archive" }
+
+func (s *ArchiveSource) Fetch(ctx context.Context) (*Batch, error) {
+	key := s.Key
+	if key == "" {
+		var err error
+		if key, err = s.Archiver.Locate(ctx, storage.DatasetRatings, s.Date); err != nil {
+			return nil, err
+		}
+	}
+
+	// the parquet schema itself tells versions 1 and 2 apart; the manifest
+	// guards against files written by a newer version of the tool
+	if manifest, err := storage.LoadManifest(ctx, s.Archiver.Store); err != nil {
+		log.Warn().Err(err).Str("Store", s.Archiver.Store.Name()).Msg("could not load archive manifest; relying on the parquet schema")
+	} else if entry := manifest.Find(key); entry != nil && entry.SchemaVersion > RatingsSchemaVersion {
+		return nil, fmt.Errorf("%s has schema version %d, newest supported is %d: %w", key, entry.SchemaVersion, RatingsSchemaVersion, ErrUnknownSchemaVersion)
+	}
+
+	reader, err := s.Archiver.Store.Get(ctx, key)
+	if err != nil {
+		log.Error().Err(err).Str("Key", key).Str("Store", s.Archiver.Store.Name()).Msg("could not download archived file")
+		return nil, err
+	}
+	defer reader.Close()
+
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		log.Error().Err(err).Str("Key", key).Str("Store", s.Archiver.Store.Name()).Msg("could not download archived file")
+		return nil, err
+	}
+
+	log.Info().Str("Key", key).Int("Size", len(data)).Msg("downloaded archived file")
+	return &Batch{Data: data, Filename: path.Base(key), DateStr: s.Date.Format("2006-01-02")}, nil
+}
+
+// Stages
+
+// ParquetParseStage parses a batch whose data is a parquet file written by ParquetSink
+type ParquetParseStage struct{}
+
+func (s *ParquetParseStage) Name() string { return "parse-parquet" }
+
+func (s *ParquetParseStage) Run(ctx context.Context, batch *Batch) error {
+	records, err := ParseParquet(batch.Data, batch.Filename)
+	if err != nil {
+		return err
+	}
+
+	for _, r := range records {
+		if r.EventDateStr != batch.DateStr {
+			log.Error().Str("EventDate", r.EventDateStr).Str("Expected", batch.DateStr).Str("FileName", batch.Filename).Msg("archived record has a different event date than its partition")
+			return fmt.Errorf("%w: %s holds records for %s", ErrDateConflict, batch.Filename, r.EventDateStr)
+		}
+	}
+
+	batch.Records = records
+	if len(records) == 0 {
+		return ErrNoRatings
+	}
+	return nil
+}
+
+// ParseStage resolves the event date of the batch and parses the CSV into records
+type ParseStage struct {
+	Limit int
+	// Strict fails the stage when the CSV header does not match ZacksRecord
+	Strict bool
+	// NAAsZero loads missing values as zero instead of null
+	NAAsZero bool
+	// Dates determines the event date when the batch does not already have one
+	Dates *DateResolver
+}
+
+func (s *ParseStage) Name() string { return "parse" }
+
+func (s *ParseStage) Run(ctx context.Context, batch *Batch) error {
+	err := resolveBatchDate(batch, s.Dates)
+	if err != nil {
+		return err
+	}
+
+	opts := RatingsOptions{
+		Limit:    s.Limit,
+		Strict:   s.Strict,
+		NAAsZero: s.NAAsZero,
+	}
+	if batch.Records, err = LoadRatings(batch.Data, batch.DateStr, opts); err != nil {
+		return err
+	}
+	log.Info().Int("NumRatings", len(batch.Records)).Msg("loaded ratings")
+	return nil
+}
+
+// resolveBatchDate sets the event date of the batch with resolver unless it already has one
+func resolveBatchDate(batch *Batch, resolver *DateResolver) error {
+	if batch.DateStr != "" {
+		return nil
+	}
+
+	if resolver == nil {
+		resolver = DefaultDateResolver("")
+	}
+
+	date, _, err := resolver.Resolve(batch)
+	if err != nil {
+		return err
+	}
+	batch.DateStr = date.Format("2006-01-02")
+	return nil
+}
+
+// RawParseStage resolves the event date of the batch and keeps every column of
+// the CSV as text, for screens with SchemaRaw
+type RawParseStage struct {
+	// Dates determines the event date when the batch does not already have one
+	Dates *DateResolver
+}
+
+func (s *RawParseStage) Name() string { return "parse-raw" }
+
+func (s *RawParseStage) Run(ctx context.Context, batch *Batch) error {
+	if err := resolveBatchDate(batch, s.Dates); err != nil {
+		return err
+	}
+
+	header, rows, err := ParseRawCSV(batch.Data)
+	if err != nil {
+		return err
+	}
+
+	batch.RawHeader = header
+	batch.RawRows = rows
+	log.Info().Str("Screen", batch.screen().Name).Int("NumRows", len(rows)).Int("NumColumns", len(header)).Msg("loaded screen")
+
+	if len(rows) == 0 {
+		return ErrNoRatings
+	}
+	return nil
+}
+
+// FigiEnricher looks up the composite figi of each record in the assets table.
+// The assets table is read once and reused for every batch the enricher sees,
+// so share one enricher between the pipelines of a run.
+type FigiEnricher struct {
+	once      sync.Once
+	tickerMap map[string]*Ticker
+	err       error
+}
+
+func (s *FigiEnricher) Name() string { return "enrich" }
+
+func (s *FigiEnricher) Run(ctx context.Context, batch *Batch) error {
+	s.once.Do(func() {
+		s.tickerMap, s.err = LoadTickerMap()
+	})
+	if s.err != nil {
+		return s.err
+	}
+
+	ApplyFigi(batch.Records, s.tickerMap)
+	return nil
+}
+
+// NonEmptyValidator fails the pipeline when the screen returned no ratings
+type NonEmptyValidator struct{}
+
+func (s *NonEmptyValidator) Name() string { return "validate" }
+
+func (s *NonEmptyValidator) Run(ctx context.Context, batch *Batch) error {
+	if len(batch.Records) == 0 {
+		log.Error().Msg("no ratings returned")
+		return ErrNoRatings
+	}
+	return nil
+}
+
+// Sinks
+
+// parquetFilename returns <dir>/zacks-YYYYMMDD.parquet for the main ratings
+// screen and <dir>/<dataset>-YYYYMMDD.parquet for other screens
+func parquetFilename(dir string, batch *Batch) string {
+	name := batch.screen().Dataset
+	if name == storage.DatasetRatings {
+		name = "zacks"
+	}
+	if dir == "" {
+		dir = batch.TmpDir
+	}
+	return fmt.Sprintf("%s/%s-%s.parquet", dir, name, strings.ReplaceAll(batch.DateStr, "-", ""))
+}
+
+// ParquetSink writes the batch to zacks-YYYYMMDD.parquet (<dataset>-YYYYMMDD.parquet
+// for screens other than the main one) in Dir, or in the batch scratch
+// directory when Dir is empty
+type ParquetSink struct {
+	Dir string
+}
+
+func (s *ParquetSink) Name() string { return "parquet" }
+
+func (s *ParquetSink) Save(ctx context.Context, batch *Batch) error {
+	fn := parquetFilename(s.Dir, batch)
+	log.Info().Str("FileName", fn).Msg("writing zacks ratings data to parquet")
+	if err := SaveToParquet(batch.Records, fn); err != nil {
+		return err
+	}
+
+	batch.ParquetFn = fn
+	return nil
+}
+
+// RawParquetSink writes a SchemaRaw batch to <dataset>-YYYYMMDD.parquet in Dir,
+// or in the batch scratch directory when Dir is empty
+type RawParquetSink struct {
+	Dir string
+}
+
+func (s *RawParquetSink) Name() string { return "parquet-raw" }
+
+func (s *RawParquetSink) Save(ctx context.Context, batch *Batch) error {
+	fn := parquetFilename(s.Dir, batch)
+	log.Info().Str("FileName", fn).Str("Screen", batch.screen().Name).Msg("writing screen data to parquet")
+	if err := SaveRawToParquet(batch.RawHeader, batch.RawRows, batch.DateStr, fn); err != nil {
+		return err
+	}
+
+	batch.ParquetFn = fn
+	return nil
+}
+
+// DatabaseSink upserts the batch into Table, zacks_financials when empty
+type DatabaseSink struct {
+	Table string
+}
+
+func (s *DatabaseSink) Name() string { return "database" }
+
+func (s *DatabaseSink) Save(ctx context.Context, batch *Batch) error {
+	table := s.Table
+	if table == "" {
+		table = RatingsTable
+	}
+
+	stats, err := SaveToTable(ctx, batch.Records, table)
+	if err != nil {
+		log.Error().Err(err).Msg("could not save to database")
+		return err
+	}
+
+	batch.LoadStats = stats
+	return nil
+}
+
+// ArchiveSink uploads the parquet file written by ParquetSink or RawParquetSink
+// to the archive under the dataset of the batch's screen and records it in the
+// archive manifest. Rows quarantined by RulesValidator are archived alongside
+// as <dataset>-rejects.
+type ArchiveSink struct {
+	Archiver *storage.Archiver
+}
+
+func (s *ArchiveSink) Name() string { return "archive" }
+
+func (s *ArchiveSink) Save(ctx context.Context, batch *Batch) error {
+	if batch.ParquetFn == "" {
+		return ErrNoParquetOutput
+	}
+
+	date, err := time.Parse("2006-01-02", batch.DateStr)
+	if err != nil {
+		return err
+	}
+
+	screen := batch.screen()
+	if _, err := s.Archiver.Archive(ctx, screen.Dataset, date, batch.ParquetFn, batch.NumRows(), screen.SchemaVersion()); err != nil {
+		return err
+	}
+
+	if batch.RejectsFn != "" {
+		_, err = s.Archiver.Archive(ctx, storage.RejectsDataset(screen.Dataset), date, batch.RejectsFn, batch.NumRejects, RejectsSchemaVersion)
+	}
+	return err
+}
@@ -1,7 +1,42 @@
 package zacks
 
+import (
+	"fmt"
+
+	"github.com/spf13/viper"
+)
+
 const (
 	HOMEPAGE_URL       string = `https://zacks.com`
 	LOGIN_URL          string = `https://www.zacks.com/logout.php`
 	STOCK_SCREENER_URL string = `https://www.zacks.com/screening/stock-screener`
+	BALANCE_SHEET_URL  string = `https://www.zacks.com/stock/quote/%s/balance-sheet?icid=quote-stock_overview-quote_nav_tracking-zcom-left_subnav_quote_navbar-balance_sheet`
 )
+
+// The URLs below default to zacks.com and can be overridden with the
+// zacks.urls.* config keys, e.g. to run against a fakezacks server
+
+func HomepageURL() string {
+	return configuredURL("zacks.urls.homepage", HOMEPAGE_URL)
+}
+
+func LoginURL() string {
+	return configuredURL("zacks.urls.login", LOGIN_URL)
+}
+
+func StockScreenerURL() string {
+	return configuredURL("zacks.urls.stock_screener", STOCK_SCREENER_URL)
+}
+
+// BalanceSheetURL returns the balance sheet page of ticker; the override must
+// contain a single %s for the ticker
+func BalanceSheetURL(ticker string) string {
+	return fmt.Sprintf(configuredURL("zacks.urls.balance_sheet", BALANCE_SHEET_URL), ticker)
+}
+
+func configuredURL(key, fallback string) string {
+	if url := viper.GetString(key); url != "" {
+		return url
+	}
+	return fallback
+}
@@ -17,48 +17,231 @@ limitations under the License.
 package zacks
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/penny-vault/import-zacks-rank/common"
 	"github.com/playwright-community/playwright-go"
 	"github.com/rs/zerolog/log"
 	"github.com/spf13/viper"
 )
 
-func EnsureLoggedIn(page playwright.Page) {
-	if _, err := page.Goto(HOMEPAGE_URL, playwright.PageGotoOptions{
-		WaitUntil: playwright.WaitUntilStateNetworkidle,
-		Timeout:   playwright.Float(10000),
-	}); err != nil {
-		log.Error().Err(err).Msg("waiting for network idle on home page timed out")
+// loggedInSelector is only on pages viewed by a logged in user
+const loggedInSelector = "#user_menu > li.welcome_usn"
+
+// botChallengeSelector matches the captcha and bot challenge widgets of the
+// common bot detection services
+const botChallengeSelector = `iframe[src*="captcha"], iframe[src*="challenges.cloudflare.com"], #challenge-form, .g-recaptcha, .h-captcha, #px-captcha`
+
+// loginErrorSelector is the message the login form shows when it is rejected
+const loginErrorSelector = `#login .error, #login [role="alert"], .login_error`
+
+// accountNoticeSelector is the account banner that reports an expired
+// subscription to a signed in user
+const accountNoticeSelector = `p.notice, div.notice, #account_notice, .subscription_notice`
+
+var (
+	botChallengeTitleRegex   = regexp.MustCompile(`(?i)just a moment|access denied|access to this page has been denied|attention required|pardon our interruption|are you a robot`)
+	badCredentialsRegex      = regexp.MustCompile(`(?i)\b(invalid|incorrect|wrong)\b.{0,40}\b(user ?name|e-?mail|password|credentials)\b|\b(user ?name|e-?mail|password|credentials)\b.{0,40}\b(invalid|incorrect|wrong)\b`)
+	subscriptionExpiredRegex = regexp.MustCompile(`(?i)\b(subscription|membership|trial)\b.{0,40}\b(expired|ended|lapsed|cancell?ed)\b`)
+)
+
+// startLoggedIn starts a browser, logs it in and arranges for it to be closed
+// as soon as ctx is done. The returned function tears the browser down and
+// must be called once it is no longer needed; it is a no-op if err is set.
+// The page is recorded for failure evidence and a failed login is captured;
+// the trace only starts once logged in so it never holds the password.
+func startLoggedIn(ctx context.Context) (page playwright.Page, evidence *common.Evidence, stop func(), err error) {
+	stop = func() {}
+	if err = ctx.Err(); err != nil {
+		return nil, nil, stop, err
+	}
+
+	page, browserContext, browser, pw, err := common.StartPlaywright(viper.GetBool("playwright.headless"))
+	if err != nil {
+		return nil, nil, stop, err
+	}
+
+	unwatch := common.CloseOnDone(ctx, browser)
+	teardown := func() {
+		unwatch()
+		common.StopPlaywright(page, browserContext, browser, pw)
 	}
 
-	locator := page.Locator("#user_menu > li.welcome_usn")
-	if visible, err := locator.IsVisible(); visible {
-		// already logged in
+	evidence = common.RecordEvidence(page)
+
+	if err = EnsureLoggedIn(page); err != nil {
+		captureEvidence(ctx, evidence, "login", err)
+		teardown()
+		return nil, nil, stop, contextErr(ctx, err)
+	}
+	evidence.StartTrace()
+
+	return page, evidence, teardown, nil
+}
+
+// EnsureLoggedIn checks whether the page's session, which may have been
+// restored from a previous run, is logged in and goes through the login form
+// only when it is not. The login is confirmed by the logged in marker on the
+// homepage and the session is saved. Failures wrap ErrBadCredentials,
+// ErrSubscriptionExpired, ErrBotChallenge, ErrSiteUnavailable or ErrLoginFailed.
+func EnsureLoggedIn(page playwright.Page) error {
+	if err := gotoChecked(page, HomepageURL()); err != nil {
+		return err
+	}
+
+	if loggedIn(page) {
+		// already logged in, e.g. with a restored session; save it again so
+		// refreshed cookies are kept
 		log.Info().Msg("user is already logged in")
-		return
-	} else if err != nil {
-		log.Error().Err(err).Msg("encountered error when checking if user logged in")
+		saveSession(page)
+		return nil
+	}
+
+	if err := classifyPage(page); err != nil {
+		return err
 	}
 
 	log.Info().Msg("need to log user in")
 
+	username := viper.GetString("zacks.username")
+	password := viper.GetString("zacks.password")
+	if username == "" || password == "" {
+		return fmt.Errorf("%w: zacks.username and zacks.password must be set", ErrBadCredentials)
+	}
+
 	// load the login page
-	if _, err := page.Goto(LOGIN_URL); err != nil {
-		log.Error().Err(err).Msg("could not load login page")
-		return
+	if err := gotoChecked(page, LoginURL()); err != nil {
+		return err
 	}
 
-	if err := page.Locator("#login input[name=username]").Fill(viper.GetString("zacks.username")); err != nil {
+	if err := classifyPage(page); err != nil {
+		return err
+	}
+
+	if err := page.Locator("#login input[name=username]").Fill(username); err != nil {
 		log.Error().Err(err).Msg("could not fill username")
-		return
+		return fmt.Errorf("%w: login form not found: %v", ErrLoginFailed, err)
 	}
 
-	if err := page.Locator("#login input[name=password]").Fill(viper.GetString("zacks.password")); err != nil {
+	if err := page.Locator("#login input[name=password]").Fill(password); err != nil {
 		log.Error().Err(err).Msg("could not fill password")
-		return
+		return fmt.Errorf("%w: login form not found: %v", ErrLoginFailed, err)
 	}
 
 	if err := page.Locator("#login input[value=Login]").Click(); err != nil {
 		log.Error().Err(err).Msg("could not click login button")
-		return
+		return fmt.Errorf("%w: login form not found: %v", ErrLoginFailed, err)
+	}
+
+	if err := page.WaitForLoadState(playwright.PageWaitForLoadStateOptions{
+		State: playwright.LoadStateNetworkidle,
+	}); err != nil {
+		log.Warn().Err(err).Msg("waiting for login to finish timed out")
+	}
+
+	// the page the form lands on explains a failed login
+	if err := classifyPage(page); err != nil {
+		return err
+	}
+
+	if err := gotoChecked(page, HomepageURL()); err != nil {
+		return err
+	}
+
+	if !loggedIn(page) {
+		if err := classifyPage(page); err != nil {
+			return err
+		}
+		log.Error().Str("Url", page.URL()).Msg("logged in marker missing after login")
+		return fmt.Errorf("%w: %s not shown after submitting the login form", ErrLoginFailed, loggedInSelector)
+	}
+
+	log.Info().Msg("logged in")
+	saveSession(page)
+	return nil
+}
+
+func loggedIn(page playwright.Page) bool {
+	visible, err := page.Locator(loggedInSelector).IsVisible()
+	if err != nil {
+		log.Error().Err(err).Msg("encountered error when checking if user logged in")
+	}
+	return visible
+}
+
+// gotoChecked loads url and returns ErrSiteUnavailable when the site cannot be
+// reached or answers with a server error. Pages that load but never go idle,
+// which ads often cause, are not an error.
+func gotoChecked(page playwright.Page, url string) error {
+	resp, err := page.Goto(url, playwright.PageGotoOptions{
+		WaitUntil: playwright.WaitUntilStateNetworkidle,
+		Timeout:   playwright.Float(10000),
+	})
+	if errors.Is(err, playwright.ErrTimeout) {
+		log.Warn().Err(err).Str("Url", url).Msg("waiting for network idle timed out")
+		return nil
+	} else if err != nil {
+		log.Error().Err(err).Str("Url", url).Msg("could not load page")
+		return fmt.Errorf("%w: %v", ErrSiteUnavailable, err)
+	}
+
+	if resp != nil && resp.Status() >= 500 {
+		log.Error().Int("Status", resp.Status()).Str("Url", url).Msg("zacks.com returned a server error")
+		return fmt.Errorf("%w: %s returned HTTP %d", ErrSiteUnavailable, url, resp.Status())
+	}
+
+	return nil
+}
+
+// classifyPage looks for a bot challenge on the current page, and for a bad
+// credentials or expired subscription message in the login form's error or
+// the account notice. The rest of the page is not searched, so a headline or
+// help link that mentions an expired subscription is not mistaken for one.
+func classifyPage(page playwright.Page) error {
+	if count, _ := page.Locator(botChallengeSelector).Count(); count > 0 {
+		log.Error().Str("Url", page.URL()).Msg("bot challenge shown")
+		return fmt.Errorf("%w at %s", ErrBotChallenge, page.URL())
+	}
+
+	if title, _ := page.Title(); botChallengeTitleRegex.MatchString(title) {
+		log.Error().Str("Url", page.URL()).Str("Title", title).Msg("bot challenge shown")
+		return fmt.Errorf("%w: %q at %s", ErrBotChallenge, title, page.URL())
+	}
+
+	loginError := elementText(page, loginErrorSelector)
+	notice := elementText(page, accountNoticeSelector)
+
+	if match := subscriptionExpiredRegex.FindString(loginError + " " + notice); match != "" {
+		log.Error().Str("Message", match).Msg("zacks.com subscription has expired")
+		return fmt.Errorf("%w: %q", ErrSubscriptionExpired, match)
+	}
+
+	if match := badCredentialsRegex.FindString(loginError); match != "" {
+		log.Error().Str("Message", match).Msg("zacks.com rejected the login")
+		return fmt.Errorf("%w: %q", ErrBadCredentials, match)
+	}
+
+	return nil
+}
+
+// elementText returns the text of every element matching selector, with
+// whitespace collapsed
+func elementText(page playwright.Page, selector string) string {
+	texts, err := page.Locator(selector).AllInnerTexts()
+	if err != nil {
+		log.Debug().Err(err).Str("Selector", selector).Msg("could not read element text")
+		return ""
+	}
+	return strings.Join(strings.Fields(strings.Join(texts, " ")), " ")
+}
+
+// saveSession persists the cookies of a logged in page for the next run
+func saveSession(page playwright.Page) {
+	if err := common.SaveSession(page.Context()); err != nil && !errors.Is(err, common.ErrSessionsDisabled) {
+		log.Warn().Err(err).Msg("could not save browser session")
 	}
 }
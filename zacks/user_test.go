@@ -0,0 +1,170 @@
+/*
+Copyright 2022
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package zacks
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/penny-vault/import-zacks-rank/common"
+	"github.com/penny-vault/import-zacks-rank/zacks/fakezacks"
+	"github.com/playwright-community/playwright-go"
+	"github.com/spf13/viper"
+)
+
+var (
+	browserOnce sync.Once
+	browserErr  error
+)
+
+// requireBrowser skips the test when playwright or its browser cannot be
+// started, e.g. because the driver is not installed
+func requireBrowser(t *testing.T) {
+	t.Helper()
+	if testing.Short() {
+		t.Skip("browser tests are skipped in short mode")
+	}
+
+	browserOnce.Do(func() {
+		pw, err := playwright.Run()
+		if err != nil {
+			browserErr = err
+			return
+		}
+		defer pw.Stop()
+
+		options, err := common.ConfiguredBrowser(true)
+		if err != nil {
+			browserErr = err
+			return
+		}
+		browser, err := options.Open(pw)
+		if err != nil {
+			browserErr = err
+			return
+		}
+		browser.Close()
+	})
+
+	if browserErr != nil {
+		t.Skipf("playwright is not available: %v", browserErr)
+	}
+}
+
+// startFakeZacks serves a fake zacks.com, points the configuration at it and
+// keeps the browser session in a file of the test's own
+func startFakeZacks(t *testing.T) *fakezacks.Server {
+	t.Helper()
+	requireBrowser(t)
+
+	fake := fakezacks.New("investor@example.com", "hunter2")
+	t.Cleanup(fake.Close)
+	t.Cleanup(viper.Reset)
+
+	fake.Configure()
+	viper.Set("playwright.headless", true)
+	viper.Set("session.file", filepath.Join(t.TempDir(), "session.enc"))
+	return fake
+}
+
+func TestEnsureLoggedInErrors(t *testing.T) {
+	tests := []struct {
+		name      string
+		setup     func(fake *fakezacks.Server)
+		want      error
+		retryable bool
+	}{
+		{"wrong password", func(fake *fakezacks.Server) { viper.Set("zacks.password", "wrong") }, ErrBadCredentials, false},
+		{"no credentials", func(fake *fakezacks.Server) { viper.Set("zacks.username", "") }, ErrBadCredentials, false},
+		{"subscription expired", func(fake *fakezacks.Server) { fake.SubscriptionExpired = true }, ErrSubscriptionExpired, false},
+		{"bot challenge", func(fake *fakezacks.Server) { fake.Challenge = true }, ErrBotChallenge, false},
+		{"outage", func(fake *fakezacks.Server) { fake.Outage = true }, ErrSiteUnavailable, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := startFakeZacks(t)
+			tt.setup(fake)
+
+			_, _, stop, err := startLoggedIn(context.Background())
+			stop()
+
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("error = %v, want %v", err, tt.want)
+			}
+			if got := Retryable(err); got != tt.retryable {
+				t.Errorf("Retryable(%v) = %t, want %t", err, got, tt.retryable)
+			}
+			if fake.Logins() > 0 && tt.want != ErrSubscriptionExpired {
+				t.Errorf("fake recorded %d logins, want none", fake.Logins())
+			}
+		})
+	}
+}
+
+// The fake's footer links mention an expired subscription and an invalid
+// password; only the login form and account notice may be read as errors.
+func TestEnsureLoggedInIgnoresPageText(t *testing.T) {
+	fake := startFakeZacks(t)
+
+	_, _, stop, err := startLoggedIn(context.Background())
+	stop()
+
+	if err != nil {
+		t.Fatalf("login failed: %v", err)
+	}
+	if fake.Logins() != 1 {
+		t.Errorf("fake recorded %d logins, want 1", fake.Logins())
+	}
+}
+
+func TestSavedSessionIsReused(t *testing.T) {
+	fake := startFakeZacks(t)
+	ctx := context.Background()
+
+	for ii := 0; ii < 2; ii++ {
+		if _, _, err := Download(ctx); err != nil {
+			t.Fatalf("download %d: %v", ii+1, err)
+		}
+	}
+
+	if fake.Logins() != 1 {
+		t.Errorf("fake recorded %d logins, want 1 with the session restored the second time", fake.Logins())
+	}
+	if fake.Downloads() != 2 {
+		t.Errorf("fake served %d downloads, want 2", fake.Downloads())
+	}
+}
+
+func TestSessionDisabledLogsInEachTime(t *testing.T) {
+	fake := startFakeZacks(t)
+	viper.Set("session.disabled", true)
+	ctx := context.Background()
+
+	for ii := 0; ii < 2; ii++ {
+		if _, _, err := Download(ctx); err != nil {
+			t.Fatalf("download %d: %v", ii+1, err)
+		}
+	}
+
+	if fake.Logins() != 2 {
+		t.Errorf("fake recorded %d logins, want 2", fake.Logins())
+	}
+}
@@ -0,0 +1,347 @@
+/*
+Copyright 2022
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package zacks
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/penny-vault/import-zacks-rank/storage"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/viper"
+	"github.com/xitongsys/parquet-go-source/local"
+	"github.com/xitongsys/parquet-go/parquet"
+	"github.com/xitongsys/parquet-go/writer"
+)
+
+// Rule is a declarative check on a single ZacksRecord field. Field is the json
+// name of the field (e.g. zacks_rank). Missing (nil or empty) values pass
+// unless Required is set.
+type Rule struct {
+	Name     string   `mapstructure:"name"`
+	Field    string   `mapstructure:"field"`
+	Required bool     `mapstructure:"required"`
+	Min      *float64 `mapstructure:"min"`
+	Max      *float64 `mapstructure:"max"`
+	OneOf    []string `mapstructure:"one_of"`
+}
+
+// RejectRecord is a row quarantined by the validation stage
+type RejectRecord struct {
+	EventDate   string `parquet:"name=event_date, type=BYTE_ARRAY, convertedtype=UTF8, encoding=PLAIN_DICTIONARY"`
+	Ticker      string `parquet:"name=ticker, type=BYTE_ARRAY, convertedtype=UTF8, encoding=PLAIN_DICTIONARY"`
+	CompanyName string `parquet:"name=company_name, type=BYTE_ARRAY, convertedtype=UTF8"`
+	Rules       string `parquet:"name=rules, type=BYTE_ARRAY, convertedtype=UTF8"`
+	Reason      string `parquet:"name=reason, type=BYTE_ARRAY, convertedtype=UTF8"`
+	Record      string `parquet:"name=record, type=BYTE_ARRAY, convertedtype=UTF8"`
+}
+
+func rangeRule(name, field string, min, max float64) *Rule {
+	return &Rule{Name: name, Field: field, Min: &min, Max: &max}
+}
+
+var styleScores = []string{"A", "B", "C", "D", "F"}
+
+// BuiltinRules returns the rules every import is checked against
+func BuiltinRules() []*Rule {
+	return []*Rule{
+		{Name: "ticker-required", Field: "ticker", Required: true},
+		rangeRule("zacks-rank-range", "zacks_rank", 1, 5),
+		{Name: "value-score-grade", Field: "value_score", OneOf: styleScores},
+		{Name: "growth-score-grade", Field: "growth_score", OneOf: styleScores},
+		{Name: "momentum-score-grade", Field: "momentum_score", OneOf: styleScores},
+		{Name: "vgm-score-grade", Field: "vgm_score", OneOf: styleScores},
+		rangeRule("last-close-plausible", "last_close", 0, 1e6),
+		rangeRule("52wk-high-plausible", "wk_high_52", 0, 1e6),
+		rangeRule("52wk-low-plausible", "wk_low_52", 0, 1e6),
+		rangeRule("target-price-plausible", "average_target_price", 0, 1e6),
+		rangeRule("price-in-52wk-range", "price_as_percent_of_52wk_hl", 0, 100),
+		rangeRule("pct-strong-buy-or-buy", "percent_rating_strong_buy_or_buy", 0, 100),
+		rangeRule("pct-strong-sell-or-sell", "percent_rating_strong_sell_or_sell", 0, 100),
+		rangeRule("pct-hold", "percent_rating_hold", 0, 100),
+		rangeRule("pct-upgrades", "percent_rating_upgrades", 0, 100),
+		rangeRule("pct-downgrades", "percent_rating_downgrades", 0, 100),
+		rangeRule("avg-broker-rec-range", "current_avg_broker_rec", 1, 5),
+	}
+}
+
+// ConfiguredRules returns the built-in rules plus those in validation.rules.
+// A configured rule with the same name as a built-in replaces it, and rules
+// named in validation.disable are dropped.
+func ConfiguredRules() ([]*Rule, error) {
+	var extra []*Rule
+	if err := viper.UnmarshalKey("validation.rules", &extra); err != nil {
+		return nil, fmt.Errorf("invalid validation.rules: %w", err)
+	}
+
+	disabled := make(map[string]bool)
+	for _, name := range viper.GetStringSlice("validation.disable") {
+		disabled[name] = true
+	}
+
+	fieldIndex := recordFieldIndex()
+	recordType := reflect.TypeOf(ZacksRecord{})
+	byName := make(map[string]int)
+	rules := make([]*Rule, 0)
+	for _, rule := range append(BuiltinRules(), extra...) {
+		if rule.Name == "" {
+			rule.Name = rule.Field
+		}
+		idx, ok := fieldIndex[rule.Field]
+		if !ok {
+			return nil, fmt.Errorf("validation rule %s: unknown field %q", rule.Name, rule.Field)
+		}
+		if err := rule.checkType(recordType.Field(idx).Type); err != nil {
+			return nil, err
+		}
+		if idx, ok := byName[rule.Name]; ok {
+			rules[idx] = rule
+			continue
+		}
+		byName[rule.Name] = len(rules)
+		rules = append(rules, rule)
+	}
+
+	enabled := make([]*Rule, 0, len(rules))
+	for _, rule := range rules {
+		if !disabled[rule.Name] {
+			enabled = append(enabled, rule)
+		}
+	}
+
+	return enabled, nil
+}
+
+// recordFieldIndex maps the json name of each ZacksRecord field to its index
+func recordFieldIndex() map[string]int {
+	recordType := reflect.TypeOf(ZacksRecord{})
+	index := make(map[string]int, recordType.NumField())
+	for ii := 0; ii < recordType.NumField(); ii++ {
+		name := strings.Split(recordType.Field(ii).Tag.Get("json"), ",")[0]
+		if name != "" && name != "-" {
+			index[name] = ii
+		}
+	}
+	return index
+}
+
+// checkType returns an error when the rule's conditions cannot apply to a
+// field of fieldType: one_of only applies to text and min and max only to numbers
+func (rule *Rule) checkType(fieldType reflect.Type) error {
+	if fieldType.Kind() == reflect.Ptr {
+		fieldType = fieldType.Elem()
+	}
+
+	switch fieldType.Kind() {
+	case reflect.String:
+		if rule.Min != nil || rule.Max != nil {
+			return fmt.Errorf("validation rule %s: min and max do not apply to text field %q", rule.Name, rule.Field)
+		}
+	case reflect.Float32, reflect.Float64, reflect.Int, reflect.Int32, reflect.Int64:
+		if len(rule.OneOf) > 0 {
+			return fmt.Errorf("validation rule %s: one_of does not apply to numeric field %q", rule.Name, rule.Field)
+		}
+	default:
+		if rule.Min != nil || rule.Max != nil || len(rule.OneOf) > 0 {
+			return fmt.Errorf("validation rule %s: min, max and one_of do not apply to field %q", rule.Name, rule.Field)
+		}
+	}
+	return nil
+}
+
+// Check returns a description of the violation, or "" if the record passes
+func (rule *Rule) Check(record *ZacksRecord, fieldIndex map[string]int) string {
+	value := reflect.ValueOf(record).Elem().Field(fieldIndex[rule.Field])
+	if value.Kind() == reflect.Ptr {
+		if value.IsNil() {
+			if rule.Required {
+				return fmt.Sprintf("%s is missing", rule.Field)
+			}
+			return ""
+		}
+		value = value.Elem()
+	}
+
+	switch value.Kind() {
+	case reflect.String:
+		str := value.String()
+		if str == "" {
+			if rule.Required {
+				return fmt.Sprintf("%s is missing", rule.Field)
+			}
+			return ""
+		}
+		if len(rule.OneOf) > 0 && !contains(rule.OneOf, str) {
+			return fmt.Sprintf("%s is %q, expected one of %s", rule.Field, str, strings.Join(rule.OneOf, ","))
+		}
+	case reflect.Float32, reflect.Float64, reflect.Int, reflect.Int32, reflect.Int64:
+		var num float64
+		if value.Kind() == reflect.Float32 || value.Kind() == reflect.Float64 {
+			num = value.Float()
+		} else {
+			num = float64(value.Int())
+		}
+		if rule.Min != nil && num < *rule.Min {
+			return fmt.Sprintf("%s is %g, below minimum %g", rule.Field, num, *rule.Min)
+		}
+		if rule.Max != nil && num > *rule.Max {
+			return fmt.Sprintf("%s is %g, above maximum %g", rule.Field, num, *rule.Max)
+		}
+	}
+
+	return ""
+}
+
+func contains(list []string, needle string) bool {
+	for _, item := range list {
+		if item == needle {
+			return true
+		}
+	}
+	return false
+}
+
+// RulesValidator removes records that break any rule from the batch, writes
+// them to <dataset>-rejects-YYYYMMDD.parquet in RejectsDir and logs a summary per rule
+type RulesValidator struct {
+	// Rules defaults to ConfiguredRules when nil
+	Rules []*Rule
+	// RejectsDir is where rejected rows are written; when empty they are only
+	// counted and logged
+	RejectsDir string
+
+	once    sync.Once
+	loadErr error
+}
+
+func (s *RulesValidator) Name() string { return "validate" }
+
+func (s *RulesValidator) Run(ctx context.Context, batch *Batch) error {
+	s.once.Do(func() {
+		if s.Rules == nil {
+			s.Rules, s.loadErr = ConfiguredRules()
+		}
+	})
+	if s.loadErr != nil {
+		log.Error().Err(s.loadErr).Msg("could not load validation rules")
+		return s.loadErr
+	}
+
+	fieldIndex := recordFieldIndex()
+	violations := make(map[string]int, len(s.Rules))
+	rejects := make([]*RejectRecord, 0)
+	accepted := make([]*ZacksRecord, 0, len(batch.Records))
+
+	for _, record := range batch.Records {
+		var names, reasons []string
+		for _, rule := range s.Rules {
+			if reason := rule.Check(record, fieldIndex); reason != "" {
+				violations[rule.Name]++
+				names = append(names, rule.Name)
+				reasons = append(reasons, reason)
+			}
+		}
+
+		if len(names) == 0 {
+			accepted = append(accepted, record)
+			continue
+		}
+
+		raw, err := json.Marshal(record)
+		if err != nil {
+			log.Warn().Err(err).Str("Ticker", record.Ticker).Msg("could not serialize rejected record")
+		}
+
+		rejects = append(rejects, &RejectRecord{
+			EventDate:   batch.DateStr,
+			Ticker:      record.Ticker,
+			CompanyName: record.CompanyName,
+			Rules:       strings.Join(names, ","),
+			Reason:      strings.Join(reasons, "; "),
+			Record:      string(raw),
+		})
+	}
+
+	ruleNames := make([]string, 0, len(violations))
+	for name := range violations {
+		ruleNames = append(ruleNames, name)
+	}
+	sort.Strings(ruleNames)
+	for _, name := range ruleNames {
+		log.Warn().Str("Rule", name).Int("Violations", violations[name]).Msg("validation rule failed")
+	}
+
+	log.Info().Int("Accepted", len(accepted)).Int("Rejected", len(rejects)).Int("Rules", len(s.Rules)).Msg("validation finished")
+
+	batch.NumRejects = len(rejects)
+	if len(rejects) > 0 && s.RejectsDir != "" {
+		fn := filepath.Join(s.RejectsDir, fmt.Sprintf("%s-%s.parquet", storage.RejectsDataset(batch.screen().Dataset), strings.ReplaceAll(batch.DateStr, "-", "")))
+		if err := SaveRejectsToParquet(rejects, fn); err != nil {
+			return err
+		}
+		batch.RejectsFn = fn
+	}
+
+	batch.Records = accepted
+	return (&NonEmptyValidator{}).Run(ctx, batch)
+}
+
+// SaveRejectsToParquet writes quarantined rows to fn
+func SaveRejectsToParquet(rejects []*RejectRecord, fn string) error {
+	if dir := filepath.Dir(fn); dir != "" {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			log.Error().Err(err).Str("Dir", dir).Msg("cannot create rejects directory")
+			return err
+		}
+	}
+
+	fh, err := local.NewLocalFileWriter(fn)
+	if err != nil {
+		log.Error().Err(err).Str("FileName", fn).Msg("cannot create local file")
+		return err
+	}
+	defer fh.Close()
+
+	pw, err := writer.NewParquetWriter(fh, new(RejectRecord), 4)
+	if err != nil {
+		log.Error().Err(err).Msg("Parquet write failed")
+		return err
+	}
+
+	pw.CompressionType = parquet.CompressionCodec_ZSTD
+
+	for _, r := range rejects {
+		if err = pw.Write(r); err != nil {
+			log.Error().Err(err).Str("Ticker", r.Ticker).Msg("Parquet write failed for reject")
+		}
+	}
+
+	if err = pw.WriteStop(); err != nil {
+		log.Error().Err(err).Msg("Parquet write failed")
+		return err
+	}
+
+	log.Info().Int("NumRecords", len(rejects)).Str("FileName", fn).Msg("wrote rejected rows")
+	return nil
+}
@@ -0,0 +1,170 @@
+/*
+Copyright 2022
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package zacks
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+func TestBuiltinRules(t *testing.T) {
+	intPtr := func(v int) *int { return &v }
+	floatPtr := func(v float64) *float64 { return &v }
+
+	tests := []struct {
+		name   string
+		record ZacksRecord
+		failed []string
+	}{
+		{"valid", ZacksRecord{Ticker: "AAPL", ZacksRank: intPtr(3), ValueScore: "C", LastClose: floatPtr(183.38)}, nil},
+		{"missing values pass", ZacksRecord{Ticker: "EXMP"}, nil},
+		{"missing ticker", ZacksRecord{ZacksRank: intPtr(3)}, []string{"ticker-required"}},
+		{"rank out of range", ZacksRecord{Ticker: "AAPL", ZacksRank: intPtr(6)}, []string{"zacks-rank-range"}},
+		{"unknown style score", ZacksRecord{Ticker: "AAPL", ValueScore: "E"}, []string{"value-score-grade"}},
+		{"negative price", ZacksRecord{Ticker: "AAPL", LastClose: floatPtr(-1)}, []string{"last-close-plausible"}},
+		{"several failures", ZacksRecord{ZacksRank: intPtr(0), AverageTargetPrice: floatPtr(2e6)}, []string{"ticker-required", "zacks-rank-range", "target-price-plausible"}},
+	}
+
+	fieldIndex := recordFieldIndex()
+	rules := BuiltinRules()
+	for _, rule := range rules {
+		if _, ok := fieldIndex[rule.Field]; !ok {
+			t.Fatalf("rule %s checks unknown field %s", rule.Name, rule.Field)
+		}
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var failed []string
+			for _, rule := range rules {
+				if reason := rule.Check(&tt.record, fieldIndex); reason != "" {
+					failed = append(failed, rule.Name)
+				}
+			}
+			if strings.Join(failed, ",") != strings.Join(tt.failed, ",") {
+				t.Errorf("expected %v to fail, got %v", tt.failed, failed)
+			}
+		})
+	}
+}
+
+func TestRulesValidatorQuarantinesRejects(t *testing.T) {
+	rank := 9
+	dir := t.TempDir()
+	batch := &Batch{
+		DateStr: "2024-05-03",
+		Records: []*ZacksRecord{
+			{Ticker: "AAPL"},
+			{Ticker: "MSFT", ZacksRank: &rank},
+		},
+	}
+
+	validator := &RulesValidator{Rules: BuiltinRules(), RejectsDir: dir}
+	if err := validator.Run(context.Background(), batch); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(batch.Records) != 1 || batch.Records[0].Ticker != "AAPL" {
+		t.Errorf("expected only AAPL to be accepted, got %d records", len(batch.Records))
+	}
+	if batch.NumRejects != 1 {
+		t.Errorf("expected 1 reject, got %d", batch.NumRejects)
+	}
+	if want := filepath.Join(dir, "ratings-rejects-20240503.parquet"); batch.RejectsFn != want {
+		t.Errorf("expected rejects in %s, got %s", want, batch.RejectsFn)
+	}
+
+	batch.Records = []*ZacksRecord{{ZacksRank: &rank}}
+	if err := validator.Run(context.Background(), batch); !errors.Is(err, ErrNoRatings) {
+		t.Errorf("expected %v when every row is rejected, got %v", ErrNoRatings, err)
+	}
+}
+
+func TestRulesValidatorWithoutRejectsDir(t *testing.T) {
+	t.Chdir(t.TempDir())
+
+	rank := 9
+	batch := &Batch{
+		DateStr: "2024-05-03",
+		Records: []*ZacksRecord{{Ticker: "AAPL"}, {Ticker: "MSFT", ZacksRank: &rank}},
+	}
+
+	validator := NewPipeline(nil).Validate
+	if err := validator.Run(context.Background(), batch); err != nil {
+		t.Fatal(err)
+	}
+
+	if batch.NumRejects != 1 || batch.RejectsFn != "" {
+		t.Errorf("expected 1 reject and no rejects file, got %d and %q", batch.NumRejects, batch.RejectsFn)
+	}
+	entries, err := os.ReadDir(".")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("a pipeline without sinks wrote %s", entries[0].Name())
+	}
+}
+
+func TestConfiguredRules(t *testing.T) {
+	t.Cleanup(viper.Reset)
+
+	tests := []struct {
+		name    string
+		rules   []map[string]any
+		disable []string
+		want    int
+		err     string
+	}{
+		{"built-in rules", nil, nil, len(BuiltinRules()), ""},
+		{"extra rule", []map[string]any{{"name": "pe-plausible", "field": "pe_trailing_12_months", "min": -10000, "max": 10000}}, nil, len(BuiltinRules()) + 1, ""},
+		{"replace a built-in", []map[string]any{{"name": "zacks-rank-range", "field": "zacks_rank", "min": 1, "max": 3}}, nil, len(BuiltinRules()), ""},
+		{"disable a built-in", nil, []string{"ticker-required"}, len(BuiltinRules()) - 1, ""},
+		{"unknown field", []map[string]any{{"field": "rank"}}, nil, 0, "unknown field"},
+		{"one_of on a number", []map[string]any{{"field": "zacks_rank", "one_of": []string{"1", "2"}}}, nil, 0, "one_of does not apply"},
+		{"min on text", []map[string]any{{"field": "sector", "min": 1}}, nil, 0, "min and max do not apply"},
+		{"max on a flag", []map[string]any{{"field": "optionable", "max": 1}}, nil, 0, "do not apply"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			viper.Reset()
+			viper.Set("validation.rules", tt.rules)
+			viper.Set("validation.disable", tt.disable)
+
+			rules, err := ConfiguredRules()
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("expected an error containing %q, got %v", tt.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(rules) != tt.want {
+				t.Errorf("expected %d rules, got %d", tt.want, len(rules))
+			}
+		})
+	}
+}